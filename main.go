@@ -1,14 +1,21 @@
 package main
 
 import (
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
+	"math"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -19,29 +26,143 @@ type Workout struct {
 	Date        string     `json:"date"`
 	WorkoutType string     `json:"workout_type"`
 	WorkoutDay  int        `json:"workout_day"`
+	IsDeload    bool       `json:"is_deload"`
+	ExpectedDay int        `json:"expected_day,omitempty"` // rotation day a GZCLP override replaced
+	ClientID    string     `json:"client_id,omitempty"`
 	Exercises   []Exercise `json:"exercises"`
 }
 
 type Exercise struct {
-	Name string `json:"name"`
-	Sets []Set  `json:"sets"`
+	ID    int    `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Slot  string `json:"slot,omitempty"`  // GZCLP slot the exercise was logged in
+	Tier  string `json:"tier,omitempty"`  // T1, T2 or T3 for GZCLP tier lifts
+	Stage string `json:"stage,omitempty"` // prescribed T1/T2 stage, e.g. 5x3
+	Sets  []Set  `json:"sets"`
 }
 
 type ExerciseDB struct {
-	ID        int    `json:"id"`
-	Name      string `json:"name"`
-	IsDefault bool   `json:"is_default"`
+	ID               int      `json:"id"`
+	Name             string   `json:"name"`
+	IsDefault        bool     `json:"is_default"`
+	PrimaryMuscles   []string `json:"primary_muscles"`
+	SecondaryMuscles []string `json:"secondary_muscles"`
+	Equipment        string   `json:"equipment"`
+	MovementPattern  string   `json:"movement_pattern"`
+	// Increment is the exercise's own progression jump in kg; zero uses
+	// the default for its muscle groups and equipment, which is reported
+	// in ProgressionIncrement.
+	Increment            float64 `json:"increment"`
+	ProgressionIncrement float64 `json:"progression_increment"`
+}
+
+// Allowed values for exercise metadata. Empty strings are accepted so
+// exercises can be created before they are classified.
+var (
+	muscleGroups     = []string{"chest", "back", "shoulders", "biceps", "triceps", "quads", "hamstrings", "glutes", "calves", "core"}
+	equipmentTypes   = []string{"barbell", "dumbbell", "machine", "cable", "bodyweight"}
+	movementPatterns = []string{"squat", "hinge", "horizontal_push", "vertical_push", "horizontal_pull", "vertical_pull", "lunge", "isolation"}
+)
+
+// Progression increments in kg. Lower-body lifts jump 5 kg, barbell
+// shoulder pressing uses microplates and everything else weightRoundingStep.
+const (
+	lowerBodyIncrement   = 5
+	microplateIncrement  = 1.25
+	maxExerciseIncrement = 20
+)
+
+var lowerBodyMuscles = []string{"quads", "hamstrings", "glutes"}
+
+// defaultIncrement is the progression jump for an exercise without its own.
+func defaultIncrement(e ExerciseDB) float64 {
+	for _, m := range e.PrimaryMuscles {
+		if contains(lowerBodyMuscles, m) && (e.Equipment == "barbell" || e.Equipment == "machine") {
+			return lowerBodyIncrement
+		}
+	}
+	if e.Equipment == "barbell" && contains(e.PrimaryMuscles, "shoulders") {
+		return microplateIncrement
+	}
+	return weightRoundingStep
+}
+
+// exerciseIncrement is the progression jump used for an exercise.
+func exerciseIncrement(e ExerciseDB) float64 {
+	if e.Increment > 0 {
+		return e.Increment
+	}
+	return defaultIncrement(e)
 }
 
+// GZCLPDayExercise is one slot of a GZCLP day. Slots are shown in Position
+// order; Tier is "T1", "T2" or "T3" for the progression tiers and empty for
+// accessories.
 type GZCLPDayExercise struct {
 	Day          int    `json:"day"`
 	Slot         string `json:"slot"`
 	ExerciseName string `json:"exercise_name"`
+	Position     int    `json:"position"`
+	Tier         string `json:"tier"`
+	// Optional prescription overriding the tier's default sets and reps.
+	// Percent is of the exercise's training max.
+	TargetSets int     `json:"sets,omitempty"`
+	TargetReps int     `json:"reps,omitempty"`
+	Percent    float64 `json:"percent,omitempty"`
 }
 
+var gzclpTiers = []string{"", "T1", "T2", "T3"}
+
+// GZCLPConfig is the whole program layout: how many days the rotation has
+// and the ordered slots of each day.
+type GZCLPConfig struct {
+	Days  int                `json:"days"`
+	Slots []GZCLPDayExercise `json:"slots"`
+}
+
+const (
+	defaultGZCLPDays = 4
+	maxGZCLPDays     = 7
+)
+
 type Set struct {
 	Weight float64 `json:"weight"`
 	Reps   int     `json:"reps"`
+	Empty  bool    `json:"empty,omitempty"` // draft set whose weight or reps is still blank
+}
+
+type PersonalRecord struct {
+	ID            int     `json:"id"`
+	WorkoutID     int     `json:"workout_id"`
+	ExerciseName  string  `json:"exercise_name"`
+	RecordType    string  `json:"record_type"`
+	Reps          int     `json:"reps"`
+	Value         float64 `json:"value"`
+	PreviousValue float64 `json:"previous_value"`
+	Date          string  `json:"date"`
+}
+
+// SaveWorkoutResult is what saveWorkoutToDB reports back about a stored workout.
+type SaveWorkoutResult struct {
+	WorkoutID int              `json:"workout_id"`
+	Records   []PersonalRecord `json:"records"`
+	Duplicate bool             `json:"duplicate,omitempty"`
+}
+
+type MeasurementType struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Unit      string `json:"unit"`
+	IsDefault bool   `json:"is_default"`
+}
+
+type Measurement struct {
+	ID     int     `json:"id"`
+	TypeID int     `json:"type_id"`
+	Type   string  `json:"type"`
+	Unit   string  `json:"unit"`
+	Date   string  `json:"date"`
+	Value  float64 `json:"value"`
 }
 
 var db *sql.DB
@@ -66,19 +187,30 @@ func initDB() {
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		date TEXT NOT NULL,
 		workout_type TEXT DEFAULT 'custom',
-		workout_day INTEGER DEFAULT 0
+		workout_day INTEGER DEFAULT 0,
+		is_deload INTEGER NOT NULL DEFAULT 0,
+		expected_day INTEGER NOT NULL DEFAULT 0,
+		client_id TEXT
 	);
 
 	CREATE TABLE IF NOT EXISTS exercise_library (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		name TEXT NOT NULL UNIQUE,
-		is_default INTEGER NOT NULL DEFAULT 0
+		is_default INTEGER NOT NULL DEFAULT 0,
+		primary_muscles TEXT NOT NULL DEFAULT '',
+		secondary_muscles TEXT NOT NULL DEFAULT '',
+		equipment TEXT NOT NULL DEFAULT '',
+		movement_pattern TEXT NOT NULL DEFAULT '',
+		increment REAL NOT NULL DEFAULT 0
 	);
 
 	CREATE TABLE IF NOT EXISTS exercises (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		workout_id INTEGER,
 		name TEXT NOT NULL,
+		slot TEXT NOT NULL DEFAULT '',
+		tier TEXT NOT NULL DEFAULT '',
+		stage TEXT NOT NULL DEFAULT '',
 		FOREIGN KEY(workout_id) REFERENCES workouts(id)
 	);
 
@@ -94,15 +226,122 @@ func initDB() {
 		id INTEGER PRIMARY KEY DEFAULT 1,
 		current_day INTEGER NOT NULL DEFAULT 1,
 		skipped_days INTEGER NOT NULL DEFAULT 0,
+		upcoming_days TEXT NOT NULL DEFAULT '[]',
+		rotation_days INTEGER NOT NULL DEFAULT 4,
 		CONSTRAINT single_row CHECK (id = 1)
 	);
 
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS gzclp_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_type TEXT NOT NULL,
+		from_day INTEGER NOT NULL DEFAULT 0,
+		to_day INTEGER NOT NULL DEFAULT 0,
+		workout_id INTEGER NOT NULL DEFAULT 0,
+		detail TEXT NOT NULL DEFAULT '',
+		data TEXT NOT NULL DEFAULT '',
+		undone INTEGER NOT NULL DEFAULT 0,
+		created_at TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS skipped_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date TEXT NOT NULL,
+		workout_day INTEGER NOT NULL,
+		exercises TEXT NOT NULL DEFAULT '[]',
+		reason TEXT NOT NULL DEFAULT '',
+		note TEXT NOT NULL DEFAULT '',
+		event_id INTEGER NOT NULL DEFAULT 0
+	);
+
 	CREATE TABLE IF NOT EXISTS gzclp_day_exercises (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		day INTEGER NOT NULL,
 		slot TEXT NOT NULL,
 		exercise_name TEXT NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		tier TEXT NOT NULL DEFAULT '',
+		sets INTEGER NOT NULL DEFAULT 0,
+		reps INTEGER NOT NULL DEFAULT 0,
+		percent REAL NOT NULL DEFAULT 0,
 		UNIQUE(day, slot)
+	);
+
+	CREATE TABLE IF NOT EXISTS measurement_types (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		unit TEXT NOT NULL DEFAULT 'cm',
+		is_default INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS measurements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type_id INTEGER NOT NULL,
+		date TEXT NOT NULL,
+		value REAL NOT NULL,
+		FOREIGN KEY(type_id) REFERENCES measurement_types(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS workout_templates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE
+	);
+
+	CREATE TABLE IF NOT EXISTS template_exercises (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		template_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		exercise_name TEXT NOT NULL,
+		sets INTEGER NOT NULL,
+		reps INTEGER NOT NULL,
+		weight REAL NOT NULL DEFAULT 0,
+		percent REAL NOT NULL DEFAULT 0,
+		FOREIGN KEY(template_id) REFERENCES workout_templates(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS workout_drafts (
+		form TEXT PRIMARY KEY,
+		data TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS personal_records (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workout_id INTEGER NOT NULL,
+		exercise_name TEXT NOT NULL,
+		record_type TEXT NOT NULL,
+		reps INTEGER NOT NULL DEFAULT 0,
+		value REAL NOT NULL,
+		previous_value REAL NOT NULL DEFAULT 0,
+		date TEXT NOT NULL,
+		FOREIGN KEY(workout_id) REFERENCES workouts(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS training_maxes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		exercise_name TEXT NOT NULL,
+		value REAL NOT NULL,
+		date TEXT NOT NULL,
+		note TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS gzclp_resets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		exercise_name TEXT NOT NULL,
+		tier TEXT NOT NULL,
+		workout_id INTEGER NOT NULL,
+		failed_stage TEXT NOT NULL,
+		failed_weight REAL NOT NULL,
+		test_weight REAL NOT NULL DEFAULT 0,
+		new_weight REAL NOT NULL,
+		dismissed INTEGER NOT NULL DEFAULT 0,
+		date TEXT NOT NULL,
+		event_id INTEGER NOT NULL DEFAULT 0,
+		after_workout_id INTEGER NOT NULL DEFAULT 0
 	);`
 
 	_, err = db.Exec(createTables)
@@ -113,66 +352,241 @@ func initDB() {
 	// Add new columns if they don't exist (migration)
 	db.Exec("ALTER TABLE workouts ADD COLUMN workout_type TEXT DEFAULT 'custom'")
 	db.Exec("ALTER TABLE workouts ADD COLUMN workout_day INTEGER DEFAULT 0")
+	db.Exec("ALTER TABLE workouts ADD COLUMN is_deload INTEGER NOT NULL DEFAULT 0")
+	db.Exec("ALTER TABLE workouts ADD COLUMN client_id TEXT")
+	db.Exec("ALTER TABLE workouts ADD COLUMN expected_day INTEGER NOT NULL DEFAULT 0")
+	db.Exec("ALTER TABLE gzclp_settings ADD COLUMN upcoming_days TEXT NOT NULL DEFAULT '[]'")
+	db.Exec("ALTER TABLE gzclp_settings ADD COLUMN rotation_days INTEGER NOT NULL DEFAULT 4")
+	if _, err := db.Exec("ALTER TABLE gzclp_day_exercises ADD COLUMN position INTEGER NOT NULL DEFAULT 0"); err == nil {
+		// Existing rows get the order and tiers of the old fixed layout
+		db.Exec(`UPDATE gzclp_day_exercises SET position = CASE slot
+			WHEN 'T1' THEN 0 WHEN 'T2' THEN 1 WHEN 'T3' THEN 2 WHEN 'Additional1' THEN 3 WHEN 'Additional2' THEN 4 ELSE 5 END`)
+	}
+	if _, err := db.Exec("ALTER TABLE gzclp_day_exercises ADD COLUMN tier TEXT NOT NULL DEFAULT ''"); err == nil {
+		db.Exec("UPDATE gzclp_day_exercises SET tier = slot WHERE slot IN ('T1', 'T2', 'T3')")
+	}
+	db.Exec("ALTER TABLE gzclp_day_exercises ADD COLUMN sets INTEGER NOT NULL DEFAULT 0")
+	db.Exec("ALTER TABLE gzclp_day_exercises ADD COLUMN reps INTEGER NOT NULL DEFAULT 0")
+	db.Exec("ALTER TABLE gzclp_day_exercises ADD COLUMN percent REAL NOT NULL DEFAULT 0")
+	db.Exec("ALTER TABLE template_exercises ADD COLUMN percent REAL NOT NULL DEFAULT 0")
+	db.Exec("ALTER TABLE exercises ADD COLUMN slot TEXT NOT NULL DEFAULT ''")
+	if _, err := db.Exec("ALTER TABLE exercises ADD COLUMN tier TEXT NOT NULL DEFAULT ''"); err == nil {
+		// Earlier GZCLP workouts take the slot their exercise has on that day now
+		db.Exec(`UPDATE exercises SET
+			slot = COALESCE((SELECT g.slot FROM gzclp_day_exercises g JOIN workouts w ON w.id = exercises.workout_id
+				WHERE w.workout_type = 'gzclp' AND g.day = w.workout_day AND g.exercise_name = exercises.name ORDER BY g.position LIMIT 1), ''),
+			tier = COALESCE((SELECT g.tier FROM gzclp_day_exercises g JOIN workouts w ON w.id = exercises.workout_id
+				WHERE w.workout_type = 'gzclp' AND g.day = w.workout_day AND g.exercise_name = exercises.name ORDER BY g.position LIMIT 1), '')`)
+	}
+	db.Exec("ALTER TABLE exercises ADD COLUMN stage TEXT NOT NULL DEFAULT ''")
+	db.Exec("ALTER TABLE gzclp_resets ADD COLUMN dismissed INTEGER NOT NULL DEFAULT 0")
+	if _, err := db.Exec("ALTER TABLE gzclp_resets ADD COLUMN after_workout_id INTEGER NOT NULL DEFAULT 0"); err == nil {
+		db.Exec("UPDATE gzclp_resets SET after_workout_id = workout_id")
+	}
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_workouts_client_id ON workouts(client_id)")
+	// A failure is only reset once, even when the reset is submitted twice
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_gzclp_resets_failure ON gzclp_resets(tier, exercise_name, workout_id)")
+	db.Exec("ALTER TABLE exercise_library ADD COLUMN primary_muscles TEXT NOT NULL DEFAULT ''")
+	db.Exec("ALTER TABLE exercise_library ADD COLUMN secondary_muscles TEXT NOT NULL DEFAULT ''")
+	db.Exec("ALTER TABLE exercise_library ADD COLUMN equipment TEXT NOT NULL DEFAULT ''")
+	db.Exec("ALTER TABLE exercise_library ADD COLUMN movement_pattern TEXT NOT NULL DEFAULT ''")
+	db.Exec("ALTER TABLE exercise_library ADD COLUMN increment REAL NOT NULL DEFAULT 0")
 	// Initialize GZCLP settings
 	db.Exec("INSERT OR IGNORE INTO gzclp_settings (id, current_day, skipped_days) VALUES (1, 1, 0)")
+	ensureGZCLPBaseline()
+	if _, err := checkGZCLPState(); err != nil {
+		log.Printf("Error checking GZCLP state: %v", err)
+	}
 
-	// Populate default exercises and GZCLP day assignments
+	// Populate default exercises, GZCLP day assignments and measurement types
 	populateDefaultExercises()
 	populateDefaultGZCLPDayExercises()
+	populateDefaultMeasurementTypes()
 }
 
 func populateDefaultExercises() {
-	exercises := []string{
-		"Squat", "Bench Press", "Deadlift", "Overhead Press",
-		"Front Squat", "Sumo Deadlift", "Lat Pulldown", "Bent Over Row",
-		"Leg Curl", "Leg Extension", "Leg Press", "Tricep Pushdown",
-		"Bicep Curl", "Calf Raise", "Lateral Raise", "Chest Fly",
+	exercises := []ExerciseDB{
+		{Name: "Squat", PrimaryMuscles: []string{"quads", "glutes"}, SecondaryMuscles: []string{"hamstrings", "core"}, Equipment: "barbell", MovementPattern: "squat"},
+		{Name: "Bench Press", PrimaryMuscles: []string{"chest"}, SecondaryMuscles: []string{"triceps", "shoulders"}, Equipment: "barbell", MovementPattern: "horizontal_push"},
+		{Name: "Deadlift", PrimaryMuscles: []string{"hamstrings", "glutes", "back"}, SecondaryMuscles: []string{"quads", "core"}, Equipment: "barbell", MovementPattern: "hinge"},
+		{Name: "Overhead Press", PrimaryMuscles: []string{"shoulders"}, SecondaryMuscles: []string{"triceps", "core"}, Equipment: "barbell", MovementPattern: "vertical_push"},
+		{Name: "Front Squat", PrimaryMuscles: []string{"quads"}, SecondaryMuscles: []string{"glutes", "core"}, Equipment: "barbell", MovementPattern: "squat"},
+		{Name: "Sumo Deadlift", PrimaryMuscles: []string{"glutes", "hamstrings"}, SecondaryMuscles: []string{"quads", "back"}, Equipment: "barbell", MovementPattern: "hinge"},
+		{Name: "Lat Pulldown", PrimaryMuscles: []string{"back"}, SecondaryMuscles: []string{"biceps"}, Equipment: "cable", MovementPattern: "vertical_pull"},
+		{Name: "Bent Over Row", PrimaryMuscles: []string{"back"}, SecondaryMuscles: []string{"biceps", "shoulders"}, Equipment: "barbell", MovementPattern: "horizontal_pull"},
+		{Name: "Leg Curl", PrimaryMuscles: []string{"hamstrings"}, Equipment: "machine", MovementPattern: "isolation"},
+		{Name: "Leg Extension", PrimaryMuscles: []string{"quads"}, Equipment: "machine", MovementPattern: "isolation"},
+		{Name: "Leg Press", PrimaryMuscles: []string{"quads", "glutes"}, SecondaryMuscles: []string{"hamstrings"}, Equipment: "machine", MovementPattern: "squat"},
+		{Name: "Tricep Pushdown", PrimaryMuscles: []string{"triceps"}, Equipment: "cable", MovementPattern: "isolation"},
+		{Name: "Bicep Curl", PrimaryMuscles: []string{"biceps"}, Equipment: "dumbbell", MovementPattern: "isolation"},
+		{Name: "Calf Raise", PrimaryMuscles: []string{"calves"}, Equipment: "machine", MovementPattern: "isolation"},
+		{Name: "Lateral Raise", PrimaryMuscles: []string{"shoulders"}, Equipment: "dumbbell", MovementPattern: "isolation"},
+		{Name: "Chest Fly", PrimaryMuscles: []string{"chest"}, SecondaryMuscles: []string{"shoulders"}, Equipment: "dumbbell", MovementPattern: "isolation"},
+	}
+
+	for _, e := range exercises {
+		db.Exec("INSERT OR IGNORE INTO exercise_library (name, is_default) VALUES (?, 1)", e.Name)
+		db.Exec("UPDATE exercise_library SET is_default = 1 WHERE name = ?", e.Name)
+		// Only prefill metadata that hasn't been classified yet so user edits survive restarts
+		db.Exec(`UPDATE exercise_library
+			SET primary_muscles = ?, secondary_muscles = ?, equipment = ?, movement_pattern = ?
+			WHERE name = ? AND primary_muscles = '' AND equipment = '' AND movement_pattern = ''`,
+			joinList(e.PrimaryMuscles), joinList(e.SecondaryMuscles), e.Equipment, e.MovementPattern, e.Name)
+	}
+}
+
+// defaultGZCLPDayExercises is the standard four-day GZCLP layout used until
+// the program is configured.
+var defaultGZCLPDayExercises = []GZCLPDayExercise{
+	// Day A1
+	{Day: 1, Slot: "T1", ExerciseName: "Squat"},
+	{Day: 1, Slot: "T2", ExerciseName: "Bench Press"},
+	{Day: 1, Slot: "T3", ExerciseName: "Lat Pulldown"},
+	{Day: 1, Slot: "Additional1", ExerciseName: "Leg Press"},
+	{Day: 1, Slot: "Additional2", ExerciseName: "Chest Fly"},
+	// Day B1
+	{Day: 2, Slot: "T1", ExerciseName: "Overhead Press"},
+	{Day: 2, Slot: "T2", ExerciseName: "Deadlift"},
+	{Day: 2, Slot: "T3", ExerciseName: "Bent Over Row"},
+	{Day: 2, Slot: "Additional1", ExerciseName: "Lateral Raise"},
+	{Day: 2, Slot: "Additional2", ExerciseName: "Leg Curl"},
+	// Day A2
+	{Day: 3, Slot: "T1", ExerciseName: "Bench Press"},
+	{Day: 3, Slot: "T2", ExerciseName: "Squat"},
+	{Day: 3, Slot: "T3", ExerciseName: "Lat Pulldown"},
+	{Day: 3, Slot: "Additional1", ExerciseName: "Chest Fly"},
+	{Day: 3, Slot: "Additional2", ExerciseName: "Leg Press"},
+	// Day B2
+	{Day: 4, Slot: "T1", ExerciseName: "Deadlift"},
+	{Day: 4, Slot: "T2", ExerciseName: "Overhead Press"},
+	{Day: 4, Slot: "T3", ExerciseName: "Bent Over Row"},
+	{Day: 4, Slot: "Additional1", ExerciseName: "Leg Curl"},
+	{Day: 4, Slot: "Additional2", ExerciseName: "Lateral Raise"},
+}
+
+// defaultGZCLPSlots returns the default slots of day in order.
+func defaultGZCLPSlots(day int) []GZCLPDayExercise {
+	var slots []GZCLPDayExercise
+	for _, d := range defaultGZCLPDayExercises {
+		if d.Day == day {
+			d.Position = len(slots)
+			d.Tier = slotTier(d.Slot)
+			slots = append(slots, d)
+		}
 	}
+	return slots
+}
 
-	for _, name := range exercises {
-		db.Exec("INSERT OR IGNORE INTO exercise_library (name, is_default) VALUES (?, 1)", name)
-		db.Exec("UPDATE exercise_library SET is_default = 1 WHERE name = ?", name)
+// slotTier is the tier implied by a slot name, for slots saved without one.
+func slotTier(slot string) string {
+	if slot == "T1" || slot == "T2" || slot == "T3" {
+		return slot
 	}
+	return ""
 }
 
+// populateDefaultGZCLPDayExercises seeds the default layout into an empty
+// table only, so slots and days removed through the config stay removed.
 func populateDefaultGZCLPDayExercises() {
-	defaults := []GZCLPDayExercise{
-		// Day A1
-		{Day: 1, Slot: "T1", ExerciseName: "Squat"},
-		{Day: 1, Slot: "T2", ExerciseName: "Bench Press"},
-		{Day: 1, Slot: "T3", ExerciseName: "Lat Pulldown"},
-		{Day: 1, Slot: "Additional1", ExerciseName: "Leg Press"},
-		{Day: 1, Slot: "Additional2", ExerciseName: "Chest Fly"},
-		// Day B1
-		{Day: 2, Slot: "T1", ExerciseName: "Overhead Press"},
-		{Day: 2, Slot: "T2", ExerciseName: "Deadlift"},
-		{Day: 2, Slot: "T3", ExerciseName: "Bent Over Row"},
-		{Day: 2, Slot: "Additional1", ExerciseName: "Lateral Raise"},
-		{Day: 2, Slot: "Additional2", ExerciseName: "Leg Curl"},
-		// Day A2
-		{Day: 3, Slot: "T1", ExerciseName: "Bench Press"},
-		{Day: 3, Slot: "T2", ExerciseName: "Squat"},
-		{Day: 3, Slot: "T3", ExerciseName: "Lat Pulldown"},
-		{Day: 3, Slot: "Additional1", ExerciseName: "Chest Fly"},
-		{Day: 3, Slot: "Additional2", ExerciseName: "Leg Press"},
-		// Day B2
-		{Day: 4, Slot: "T1", ExerciseName: "Deadlift"},
-		{Day: 4, Slot: "T2", ExerciseName: "Overhead Press"},
-		{Day: 4, Slot: "T3", ExerciseName: "Bent Over Row"},
-		{Day: 4, Slot: "Additional1", ExerciseName: "Leg Curl"},
-		{Day: 4, Slot: "Additional2", ExerciseName: "Lateral Raise"},
-	}
-
-	for _, d := range defaults {
-		db.Exec("INSERT OR IGNORE INTO gzclp_day_exercises (day, slot, exercise_name) VALUES (?, ?, ?)",
-			d.Day, d.Slot, d.ExerciseName)
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM gzclp_day_exercises").Scan(&count)
+	if count > 0 {
+		return
+	}
+	for day := 1; day <= defaultGZCLPDays; day++ {
+		for _, d := range defaultGZCLPSlots(day) {
+			db.Exec("INSERT OR IGNORE INTO gzclp_day_exercises (day, slot, exercise_name, position, tier) VALUES (?, ?, ?, ?, ?)",
+				d.Day, d.Slot, d.ExerciseName, d.Position, d.Tier)
+		}
+	}
+}
+
+func populateDefaultMeasurementTypes() {
+	defaults := []MeasurementType{
+		{Name: "Body Weight", Unit: "kg"},
+		{Name: "Body Fat", Unit: "%"},
+		{Name: "Waist", Unit: "cm"},
+		{Name: "Chest", Unit: "cm"},
+		{Name: "Arm", Unit: "cm"},
+		{Name: "Thigh", Unit: "cm"},
+	}
+
+	for _, m := range defaults {
+		db.Exec("INSERT OR IGNORE INTO measurement_types (name, unit, is_default) VALUES (?, ?, 1)", m.Name, m.Unit)
+		db.Exec("UPDATE measurement_types SET is_default = 1 WHERE name = ?", m.Name)
+	}
+}
+
+// joinList stores a list of metadata values in a single comma-separated column.
+func joinList(values []string) string {
+	return strings.Join(values, ",")
+}
+
+// splitList is the inverse of joinList. It always returns a non-nil slice so
+// the JSON encoding is an empty array rather than null.
+func splitList(value string) []string {
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validateExerciseMetadata checks metadata against the allowed values and
+// returns a user-facing error message, or "" if everything is valid.
+func validateExerciseMetadata(exercise ExerciseDB) string {
+	for _, m := range append(append([]string{}, exercise.PrimaryMuscles...), exercise.SecondaryMuscles...) {
+		if !contains(muscleGroups, m) {
+			return fmt.Sprintf("Unknown muscle group: %s", m)
+		}
+	}
+	if exercise.Equipment != "" && !contains(equipmentTypes, exercise.Equipment) {
+		return fmt.Sprintf("Unknown equipment: %s", exercise.Equipment)
+	}
+	if exercise.MovementPattern != "" && !contains(movementPatterns, exercise.MovementPattern) {
+		return fmt.Sprintf("Unknown movement pattern: %s", exercise.MovementPattern)
+	}
+	if exercise.Increment < 0 || exercise.Increment > maxExerciseIncrement {
+		return fmt.Sprintf("Increment must be between 0 and %d kg", maxExerciseIncrement)
+	}
+	return ""
+}
+
+// filterExercises keeps exercises matching every non-empty filter. A muscle
+// group matches if it is either a primary or a secondary muscle.
+func filterExercises(exercises []ExerciseDB, muscle, equipment, pattern string) []ExerciseDB {
+	filtered := []ExerciseDB{}
+	for _, e := range exercises {
+		if muscle != "" && !contains(e.PrimaryMuscles, muscle) && !contains(e.SecondaryMuscles, muscle) {
+			continue
+		}
+		if equipment != "" && e.Equipment != equipment {
+			continue
+		}
+		if pattern != "" && e.MovementPattern != pattern {
+			continue
+		}
+		filtered = append(filtered, e)
 	}
+	return filtered
 }
 
 func getAllExercises() ([]ExerciseDB, error) {
 	var exercises []ExerciseDB
 
-	query := "SELECT id, name, is_default FROM exercise_library ORDER BY name"
+	query := `SELECT id, name, is_default, primary_muscles, secondary_muscles, equipment, movement_pattern, increment
+		FROM exercise_library ORDER BY name`
 
 	rows, err := db.Query(query)
 	if err != nil {
@@ -182,36 +596,86 @@ func getAllExercises() ([]ExerciseDB, error) {
 
 	for rows.Next() {
 		var exercise ExerciseDB
-		err := rows.Scan(&exercise.ID, &exercise.Name, &exercise.IsDefault)
+		var primary, secondary string
+		err := rows.Scan(&exercise.ID, &exercise.Name, &exercise.IsDefault, &primary, &secondary,
+			&exercise.Equipment, &exercise.MovementPattern, &exercise.Increment)
 		if err != nil {
 			return nil, err
 		}
+		exercise.PrimaryMuscles = splitList(primary)
+		exercise.SecondaryMuscles = splitList(secondary)
+		exercise.ProgressionIncrement = exerciseIncrement(exercise)
 		exercises = append(exercises, exercise)
 	}
 
 	return exercises, nil
 }
 
+// getExerciseIncrements maps each library exercise to its progression
+// increment. Use incrementFor to look up exercises that may be missing.
+func getExerciseIncrements() (map[string]float64, error) {
+	exercises, err := getAllExercises()
+	if err != nil {
+		return nil, err
+	}
+	increments := map[string]float64{}
+	for _, e := range exercises {
+		increments[e.Name] = e.ProgressionIncrement
+	}
+	return increments, nil
+}
+
+// incrementFor is the progression increment of an exercise, falling back to
+// weightRoundingStep for exercises outside the library.
+func incrementFor(increments map[string]float64, name string) float64 {
+	if increment, ok := increments[name]; ok {
+		return increment
+	}
+	return weightRoundingStep
+}
+
 func main() {
 	initDB()
 	defer db.Close()
 
 	// Static file serving
 	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static/"))))
+	http.HandleFunc("/sw.js", serviceWorker)
 
 	http.HandleFunc("/", home)
-	http.HandleFunc("/workout/new", newWorkoutForm)            // Show form to log workout
-	http.HandleFunc("/workout/create", createWorkout)          // Handle form submission
-	http.HandleFunc("/workouts", listWorkouts)                 // Show all logged workouts
-	http.HandleFunc("/gzclp", gzclpForm)                       // GZCLP workout form
-	http.HandleFunc("/gzclp/skip", skipGZCLPDay)               // Skip GZCLP workout day
-	http.HandleFunc("/workout/delete", deleteWorkout)          // Delete workout endpoint
-	http.HandleFunc("/statistics", statisticsPage)             // Statistics page
-	http.HandleFunc("/exercises", exercisesPage)                // Exercise management page
-	http.HandleFunc("/api/exercises", handleExercisesAPI)       // Exercise CRUD API
-	http.HandleFunc("/api/gzclp/config", handleGZCLPConfigAPI) // GZCLP day config API
-	http.HandleFunc("/api/latest-exercise", getLatestExercise)  // API endpoint for latest exercise data
-	http.HandleFunc("/api/statistics", getStatisticsData)       // API endpoint for statistics data
+	http.HandleFunc("/workout/new", newWorkoutForm)                            // Show form to log workout
+	http.HandleFunc("/workout/create", createWorkout)                          // Handle form submission
+	http.HandleFunc("/workouts", listWorkouts)                                 // Show all logged workouts
+	http.HandleFunc("/gzclp", gzclpForm)                                       // GZCLP workout form
+	http.HandleFunc("/gzclp/skip", skipGZCLPDay)                               // Skip GZCLP workout day
+	http.HandleFunc("/workout/delete", deleteWorkout)                          // Delete workout endpoint
+	http.HandleFunc("/statistics", statisticsPage)                             // Statistics page
+	http.HandleFunc("/exercises", exercisesPage)                               // Exercise management page
+	http.HandleFunc("/api/exercises", handleExercisesAPI)                      // Exercise CRUD API
+	http.HandleFunc("/api/gzclp/config", handleGZCLPConfigAPI)                 // GZCLP day config API
+	http.HandleFunc("/api/gzclp/history", getGZCLPHistoryAPI)                  // Program event timeline and derived state
+	http.HandleFunc("/api/gzclp/adherence", getAdherenceAPI)                   // Planned vs completed vs skipped sessions
+	http.HandleFunc("/api/gzclp/day", changeGZCLPDayAPI)                       // Jump, go back or swap the next GZCLP day
+	http.HandleFunc("/api/gzclp/undo", undoGZCLPEventAPI)                      // Undo the last program event
+	http.HandleFunc("/api/gzclp/deload", getDeloadStatusAPI)                   // Whether the next session should be a deload
+	http.HandleFunc("/api/gzclp/resets", handleGZCLPResetsAPI)                 // Lifts needing a reset and reset history
+	http.HandleFunc("/api/latest-exercise", getLatestExercise)                 // API endpoint for latest exercise data
+	http.HandleFunc("/api/statistics", getStatisticsData)                      // API endpoint for statistics data
+	http.HandleFunc("/api/statistics/muscle-groups", getMuscleGroupStatistics) // Weekly sets/tonnage per muscle group
+	http.HandleFunc("/api/calendar", getCalendarData)                          // Training heatmap and weekly streaks
+	http.HandleFunc("/measurements", measurementsPage)                         // Body measurements page
+	http.HandleFunc("/api/measurements", handleMeasurementsAPI)                // Measurement CRUD API
+	http.HandleFunc("/api/measurement-types", handleMeasurementTypesAPI)       // Measurement type CRUD API
+	http.HandleFunc("/records", recordsPage)                                   // PR history page
+	http.HandleFunc("/api/records", handleRecordsAPI)                          // PR history and rep maxes per exercise
+	http.HandleFunc("/templates", templatesPage)                               // Workout templates page
+	http.HandleFunc("/api/templates", handleTemplatesAPI)                      // Workout template CRUD API
+	http.HandleFunc("/api/training-max", handleTrainingMaxAPI)                 // Training max history per exercise
+	http.HandleFunc("/api/drafts", handleDraftsAPI)                            // In-progress workout autosave
+	http.HandleFunc("/api/drafts/finish", finishDraft)                         // Convert a draft into a logged workout
+	http.HandleFunc("/api/sync", syncWorkouts)                                 // Replay workouts queued while offline
+	http.HandleFunc("/api/settings", handleSettingsAPI)                        // App-wide settings API
+	http.HandleFunc("/api/export", exportData)                                 // Full JSON backup download
 
 	log.Println("Starting server on :8081")
 	err := http.ListenAndServe(":8081", nil)
@@ -226,13 +690,158 @@ func home(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = tmpl.Execute(w, nil)
+	dashboard, err := buildDashboard(time.Now())
+	if err != nil {
+		log.Printf("Error building dashboard: %v", err)
+	}
+
+	data := struct {
+		Dashboard
+		NewRecords []PersonalRecord
+	}{Dashboard: dashboard}
+	if prs := r.URL.Query().Get("prs"); prs != "" {
+		if workoutID, err := strconv.Atoi(prs); err == nil {
+			data.NewRecords, _ = getPersonalRecords("", workoutID)
+		}
+	}
+
+	err = tmpl.Execute(w, data)
 	if err != nil {
 		http.Error(w, "Template execution error", http.StatusInternalServerError)
 		log.Printf("Error executing home template: %v", err)
 	}
 }
 
+// dashboardRecentRecords and dashboardSparklineSessions bound how much
+// history the home page summarises.
+const (
+	dashboardRecentRecords     = 5
+	dashboardSparklineSessions = 12
+)
+
+type LiftSparkline struct {
+	Name   string
+	Latest float64
+	Points string // SVG polyline points, empty with fewer than two sessions
+}
+
+type Dashboard struct {
+	NextGZCLPDay         int
+	NextGZCLPExercises   []GZCLPDayExercise
+	LastWorkoutDate      string
+	DaysSinceLastWorkout int
+	WeekSessions         int
+	WeekTonnage          float64
+	RecentRecords        []PersonalRecord
+	MainLifts            []LiftSparkline
+}
+
+// buildDashboard gathers the home page summary as of today. The main lifts are
+// the T1 exercises of the GZCLP rotation.
+func buildDashboard(today time.Time) (Dashboard, error) {
+	var dashboard Dashboard
+	todayStr := today.Format("2006-01-02")
+
+	day, err := getNextGZCLPWorkoutDay()
+	if err != nil {
+		return dashboard, err
+	}
+	dashboard.NextGZCLPDay = day
+	dashboard.NextGZCLPExercises = getGZCLPDaySlots(day)
+
+	err = db.QueryRow("SELECT date FROM workouts WHERE date <= ? ORDER BY date DESC LIMIT 1", todayStr).Scan(&dashboard.LastWorkoutDate)
+	if err != nil && err != sql.ErrNoRows {
+		return dashboard, err
+	}
+	if last, err := time.Parse("2006-01-02", dashboard.LastWorkoutDate); err == nil {
+		midnight, _ := time.Parse("2006-01-02", todayStr)
+		dashboard.DaysSinceLastWorkout = int(midnight.Sub(last).Hours() / 24)
+	}
+
+	monday, _ := weekStart(todayStr)
+	err = db.QueryRow(`
+		SELECT COUNT(DISTINCT w.id), COALESCE(SUM(s.weight * s.reps), 0)
+		FROM workouts w
+		LEFT JOIN exercises e ON e.workout_id = w.id
+		LEFT JOIN sets s ON s.exercise_id = e.id
+		WHERE w.date >= ? AND w.date <= ?
+	`, monday, todayStr).Scan(&dashboard.WeekSessions, &dashboard.WeekTonnage)
+	if err != nil {
+		return dashboard, err
+	}
+
+	records, err := getPersonalRecords("", 0)
+	if err != nil {
+		return dashboard, err
+	}
+	if len(records) > dashboardRecentRecords {
+		records = records[:dashboardRecentRecords]
+	}
+	dashboard.RecentRecords = records
+
+	rows, err := db.Query(`SELECT exercise_name FROM gzclp_day_exercises WHERE tier = 'T1'
+		GROUP BY exercise_name ORDER BY MIN(day), exercise_name`)
+	if err != nil {
+		return dashboard, err
+	}
+	var mainLifts []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err == nil {
+			mainLifts = append(mainLifts, name)
+		}
+	}
+	rows.Close()
+
+	formula := getDefaultOneRMFormula()
+	for _, name := range mainLifts {
+		stats, err := buildExerciseStatistics(name, StatisticsFilter{Formula: formula, Level: "session", To: todayStr})
+		if err != nil {
+			return dashboard, err
+		}
+		if len(stats) == 0 {
+			continue
+		}
+		if len(stats) > dashboardSparklineSessions {
+			stats = stats[len(stats)-dashboardSparklineSessions:]
+		}
+		values := make([]float64, len(stats))
+		for i, point := range stats {
+			values[i] = point.Estimated1RM
+		}
+		dashboard.MainLifts = append(dashboard.MainLifts, LiftSparkline{
+			Name:   name,
+			Latest: values[len(values)-1],
+			Points: sparklinePoints(values, 100, 24),
+		})
+	}
+
+	return dashboard, nil
+}
+
+// sparklinePoints scales values into an SVG polyline of the given size.
+func sparklinePoints(values []float64, width, height float64) string {
+	if len(values) < 2 {
+		return ""
+	}
+	low, high := values[0], values[0]
+	for _, v := range values {
+		low = math.Min(low, v)
+		high = math.Max(high, v)
+	}
+
+	points := make([]string, len(values))
+	for i, v := range values {
+		x := width * float64(i) / float64(len(values)-1)
+		y := height / 2
+		if high > low {
+			y = height - height*(v-low)/(high-low)
+		}
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+	return strings.Join(points, " ")
+}
+
 func newWorkoutForm(w http.ResponseWriter, r *http.Request) {
 	exercises, err := getAllExercises()
 	if err != nil {
@@ -240,13 +849,70 @@ func newWorkoutForm(w http.ResponseWriter, r *http.Request) {
 		exercises = []ExerciseDB{}
 	}
 
+	templates, err := getWorkoutTemplates()
+	if err != nil {
+		log.Printf("Error loading workout templates: %v", err)
+	}
+
+	// ?template=ID prefills the form from a saved template, and
+	// ?repeat=ID from a past workout. The repeat adds &increment=kg to
+	// every exercise, or with &progress=true each exercise's own increment
+	var templateID int
+	prefill := []Exercise{}
+	if id, err := strconv.Atoi(r.URL.Query().Get("template")); err == nil {
+		if t, err := getWorkoutTemplate(id); err == nil {
+			trainingMaxes, err := getCurrentTrainingMaxes()
+			if err != nil {
+				log.Printf("Error loading training maxes: %v", err)
+			}
+			increments, err := getExerciseIncrements()
+			if err != nil {
+				log.Printf("Error loading progression increments: %v", err)
+			}
+			templateID = t.ID
+			prefill = t.toExercises(trainingMaxes, increments)
+		}
+	} else if id, err := strconv.Atoi(r.URL.Query().Get("repeat")); err == nil {
+		if workout, err := getWorkoutByID(id); err == nil {
+			increment, _ := strconv.ParseFloat(r.URL.Query().Get("increment"), 64)
+			progress, _ := strconv.ParseBool(r.URL.Query().Get("progress"))
+			var library map[string]float64
+			if progress {
+				if library, err = getExerciseIncrements(); err != nil {
+					log.Printf("Error loading progression increments: %v", err)
+				}
+			}
+			increments := map[string]float64{}
+			for _, exercise := range workout.Exercises {
+				if progress {
+					increments[exercise.Name] = incrementFor(library, exercise.Name)
+				} else {
+					increments[exercise.Name] = increment
+				}
+			}
+			prefill = repeatExercises(workout, increments)
+		}
+	}
+
 	tmpl := template.Must(template.ParseFiles("templates/workout_form.html"))
 	data := struct {
-		Today     string
-		Exercises []ExerciseDB
+		Today          string
+		Exercises      []ExerciseDB
+		MuscleGroups   []string
+		EquipmentTypes []string
+		Templates      []WorkoutTemplate
+		TemplateID     int
+		Prefill        []Exercise
+		FormToken      string
 	}{
-		Today:     time.Now().Format("2006-01-02"),
-		Exercises: exercises,
+		Today:          time.Now().Format("2006-01-02"),
+		Exercises:      exercises,
+		MuscleGroups:   muscleGroups,
+		EquipmentTypes: equipmentTypes,
+		Templates:      templates,
+		TemplateID:     templateID,
+		Prefill:        prefill,
+		FormToken:      newFormToken(),
 	}
 	tmpl.Execute(w, data)
 }
@@ -273,11 +939,22 @@ func createWorkout(w http.ResponseWriter, r *http.Request) {
 		workoutDay, _ = strconv.Atoi(workoutDayStr)
 	}
 
+	isDeload, _ := strconv.ParseBool(r.FormValue("is_deload"))
+
+	// The form token makes resubmitting the same form (e.g. a double tap)
+	// return the first save; API clients can send an Idempotency-Key header
+	idempotencyKey := r.FormValue("idempotency_key")
+	if idempotencyKey == "" {
+		idempotencyKey = r.Header.Get("Idempotency-Key")
+	}
+
 	// Create new workout
 	workout := Workout{
 		Date:        date,
 		WorkoutType: workoutType,
 		WorkoutDay:  workoutDay,
+		IsDeload:    isDeload,
+		ClientID:    idempotencyKey,
 		Exercises:   []Exercise{},
 	}
 
@@ -291,8 +968,15 @@ func createWorkout(w http.ResponseWriter, r *http.Request) {
 
 		// Create exercise
 		exercise := Exercise{
-			Name: exerciseName,
-			Sets: []Set{},
+			Name:  exerciseName,
+			Slot:  r.FormValue(fmt.Sprintf("slot_%d", exerciseIndex)),
+			Tier:  r.FormValue(fmt.Sprintf("tier_%d", exerciseIndex)),
+			Stage: r.FormValue(fmt.Sprintf("stage_%d", exerciseIndex)),
+			Sets:  []Set{},
+		}
+		if msg := validateExerciseTier(exercise); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
 		}
 
 		// Parse sets for this exercise, skipping empty ones
@@ -344,146 +1028,533 @@ func createWorkout(w http.ResponseWriter, r *http.Request) {
 		exerciseIndex++
 	}
 
-	// Save workout to database
-	err := saveWorkoutToDB(workout)
-	if err != nil {
+	// Save workout to database. A GZCLP workout for a day other than the
+	// current one is only accepted when the user confirmed the override.
+	override, _ := strconv.ParseBool(r.FormValue("gzclp_override"))
+	result, err := recordWorkout(workout, override)
+	if conflict, ok := err.(*GZCLPDayConflict); ok {
+		renderGZCLPConflict(w, r, conflict)
+		return
+	} else if err != nil {
 		http.Error(w, "Failed to save workout", http.StatusInternalServerError)
 		log.Printf("Error saving workout: %v", err)
 		return
 	}
 
-	// If this is a GZCLP workout, advance the day counter
-	if workout.WorkoutType == "gzclp" {
-		currentDay := workout.WorkoutDay
-		nextDay := (currentDay % 4) + 1
-		_, err = db.Exec(`
-			UPDATE gzclp_settings
-			SET current_day = ?
-			WHERE id = 1
-		`, nextDay)
-		if err != nil {
-			log.Printf("Error advancing GZCLP day: %v", err)
-		} else {
-			log.Printf("Advanced GZCLP from day %d to day %d", currentDay, nextDay)
+	// The autosaved draft of this form is no longer needed
+	if form := r.FormValue("draft_form"); form != "" {
+		if err := deleteDraft(form); err != nil {
+			log.Printf("Error deleting draft: %v", err)
 		}
 	}
 
-	// Redirect to success page or home
+	// API clients get the saved workout and any new PRs back as JSON
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	// Redirect home, where new PRs are celebrated
+	if len(result.Records) > 0 {
+		http.Redirect(w, r, fmt.Sprintf("/?prs=%d", result.WorkoutID), http.StatusSeeOther)
+		return
+	}
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
-func saveWorkoutToDB(workout Workout) error {
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
+// GZCLPDayConflict is returned when a GZCLP workout is submitted for a day
+// other than the one the rotation is on, e.g. from a stale form or a second
+// device that already logged the day.
+type GZCLPDayConflict struct {
+	SubmittedDay int `json:"submitted_day"`
+	CurrentDay   int `json:"current_day"`
+}
 
-	// Insert workout
-	result, err := tx.Exec("INSERT INTO workouts (date, workout_type, workout_day) VALUES (?, ?, ?)",
-		workout.Date, workout.WorkoutType, workout.WorkoutDay)
-	if err != nil {
-		return err
-	}
+func (c *GZCLPDayConflict) Error() string {
+	return fmt.Sprintf("GZCLP day %d was submitted, but the program is on day %d", c.SubmittedDay, c.CurrentDay)
+}
 
-	workoutID, err := result.LastInsertId()
-	if err != nil {
-		return err
-	}
+type FormField struct {
+	Name  string
+	Value string
+}
 
-	// Insert exercises and sets
-	for _, exercise := range workout.Exercises {
-		exerciseResult, err := tx.Exec("INSERT INTO exercises (workout_id, name) VALUES (?, ?)", workoutID, exercise.Name)
-		if err != nil {
-			return err
-		}
+// renderGZCLPConflict answers a GZCLP submission for the wrong day. API
+// clients get the conflict as JSON; the form gets a page that can resubmit
+// the same values as an explicit override.
+func renderGZCLPConflict(w http.ResponseWriter, r *http.Request, conflict *GZCLPDayConflict) {
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(conflict)
+		return
+	}
 
-		exerciseID, err := exerciseResult.LastInsertId()
-		if err != nil {
-			return err
+	keys := make([]string, 0, len(r.PostForm))
+	for key := range r.PostForm {
+		if key != "gzclp_override" {
+			keys = append(keys, key)
 		}
-
-		// Insert sets for this exercise
-		for _, set := range exercise.Sets {
-			_, err := tx.Exec("INSERT INTO sets (exercise_id, reps, weight) VALUES (?, ?, ?)", exerciseID, set.Reps, set.Weight)
-			if err != nil {
-				return err
-			}
+	}
+	sort.Strings(keys)
+	fields := []FormField{}
+	for _, key := range keys {
+		for _, value := range r.PostForm[key] {
+			fields = append(fields, FormField{Name: key, Value: value})
 		}
 	}
 
-	return tx.Commit()
+	tmpl, err := template.ParseFiles("templates/gzclp_conflict.html")
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		log.Printf("Error parsing GZCLP conflict template: %v", err)
+		return
+	}
+	w.WriteHeader(http.StatusConflict)
+	err = tmpl.Execute(w, struct {
+		Conflict *GZCLPDayConflict
+		Fields   []FormField
+	}{conflict, fields})
+	if err != nil {
+		log.Printf("Error executing GZCLP conflict template: %v", err)
+	}
 }
 
-func getWorkoutsFromDB() ([]Workout, error) {
+// recordWorkout saves a finished workout and, for GZCLP workouts, advances
+// the rotation to the day after the one logged, all in one transaction. The
+// submitted day must match the current day unless override is set, in
+// which case the replaced day is kept on the workout as ExpectedDay. A
+// workout whose ClientID was already saved is not saved again; the original
+// result is returned instead.
+func recordWorkout(workout Workout, override bool) (SaveWorkoutResult, error) {
+	if workout.ClientID != "" {
+		if id, err := getWorkoutIDByClientID(workout.ClientID); err == nil {
+			return savedWorkoutResult(id)
+		} else if err != sql.ErrNoRows {
+			return SaveWorkoutResult{}, err
+		}
+	}
+
+	formula := getDefaultOneRMFormula()
+	tx, err := db.Begin()
+	if err != nil {
+		return SaveWorkoutResult{}, err
+	}
+	defer tx.Rollback()
+
+	var state GZCLPState
+	var currentDay int
+	if workout.WorkoutType == "gzclp" {
+		if state, err = loadGZCLPState(tx); err != nil {
+			return SaveWorkoutResult{}, err
+		}
+		currentDay = state.CurrentDay
+		if workout.WorkoutDay != currentDay {
+			if !override {
+				return SaveWorkoutResult{}, &GZCLPDayConflict{SubmittedDay: workout.WorkoutDay, CurrentDay: currentDay}
+			}
+			workout.ExpectedDay = currentDay
+		}
+	}
+
+	result, err := insertWorkout(tx, workout, formula)
+	if err != nil {
+		tx.Rollback()
+		// A concurrent submission with the same key may have won the insert
+		if workout.ClientID != "" {
+			if id, lookupErr := getWorkoutIDByClientID(workout.ClientID); lookupErr == nil {
+				return savedWorkoutResult(id)
+			}
+		}
+		return result, err
+	}
+
+	if workout.WorkoutType == "gzclp" {
+		// An override leaves any swapped order behind and continues
+		// from the logged day.
+		if workout.ExpectedDay > 0 {
+			state.Upcoming = nil
+		}
+		nextDay, upcoming := state.advance(workout.WorkoutDay)
+		detail := fmt.Sprintf("Completed day %d", workout.WorkoutDay)
+		if workout.ExpectedDay > 0 {
+			detail = fmt.Sprintf("Completed day %d instead of day %d", workout.WorkoutDay, workout.ExpectedDay)
+		}
+		_, _, err = applyGZCLPEvent(tx, GZCLPEvent{Type: "workout", FromDay: currentDay, ToDay: nextDay, WorkoutID: result.WorkoutID,
+			Detail: detail, Data: encodeDays(upcoming)})
+		if err == errGZCLPStateChanged {
+			// A concurrent save moved the rotation on from the day read above
+			tx.Rollback()
+			day, _ := getNextGZCLPWorkoutDay()
+			return SaveWorkoutResult{}, &GZCLPDayConflict{SubmittedDay: workout.WorkoutDay, CurrentDay: day}
+		}
+		if err != nil {
+			return result, err
+		}
+		log.Printf("Advanced GZCLP from day %d to day %d", workout.WorkoutDay, nextDay)
+	}
+
+	return result, tx.Commit()
+}
+
+// saveWorkoutToDB saves a workout without touching the GZCLP rotation.
+func saveWorkoutToDB(workout Workout) (SaveWorkoutResult, error) {
+	formula := getDefaultOneRMFormula()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return SaveWorkoutResult{}, err
+	}
+	defer tx.Rollback()
+
+	saved, err := insertWorkout(tx, workout, formula)
+	if err != nil {
+		return saved, err
+	}
+	return saved, tx.Commit()
+}
+
+// insertWorkout writes a workout with its exercises and sets and detects
+// the personal records it sets.
+func insertWorkout(tx *sql.Tx, workout Workout, formula string) (SaveWorkoutResult, error) {
+	var saved SaveWorkoutResult
+
+	// Insert workout
+	clientID := sql.NullString{String: workout.ClientID, Valid: workout.ClientID != ""}
+	result, err := tx.Exec("INSERT INTO workouts (date, workout_type, workout_day, is_deload, expected_day, client_id) VALUES (?, ?, ?, ?, ?, ?)",
+		workout.Date, workout.WorkoutType, workout.WorkoutDay, workout.IsDeload, workout.ExpectedDay, clientID)
+	if err != nil {
+		return saved, err
+	}
+
+	workoutID, err := result.LastInsertId()
+	if err != nil {
+		return saved, err
+	}
+	saved.WorkoutID = int(workoutID)
+
+	// Insert exercises and sets
+	for _, exercise := range workout.Exercises {
+		exerciseResult, err := tx.Exec("INSERT INTO exercises (workout_id, name, slot, tier, stage) VALUES (?, ?, ?, ?, ?)",
+			workoutID, exercise.Name, exercise.Slot, exercise.Tier, exercise.Stage)
+		if err != nil {
+			return saved, err
+		}
+
+		exerciseID, err := exerciseResult.LastInsertId()
+		if err != nil {
+			return saved, err
+		}
+
+		// Insert sets for this exercise
+		for _, set := range exercise.Sets {
+			_, err := tx.Exec("INSERT INTO sets (exercise_id, reps, weight) VALUES (?, ?, ?)", exerciseID, set.Reps, set.Weight)
+			if err != nil {
+				return saved, err
+			}
+		}
+	}
+
+	saved.Records, err = detectPersonalRecords(tx, saved.WorkoutID, workout, formula)
+	return saved, err
+}
+
+// maxRepMaxReps is the highest rep count tracked for rep-max PRs.
+const maxRepMaxReps = 20
+
+// detectPersonalRecords compares every exercise in a freshly inserted workout
+// against earlier history and stores rep-max, e1RM and volume PRs. An exercise
+// needs prior history to set a record, so first-time lifts don't flood the
+// PR list.
+func detectPersonalRecords(tx *sql.Tx, workoutID int, workout Workout, formula string) ([]PersonalRecord, error) {
+	records := []PersonalRecord{}
+
+	// Merge the session by exercise name in case an exercise appears twice
+	var names []string
+	sessionSets := make(map[string][]Set)
+	for _, exercise := range workout.Exercises {
+		if _, seen := sessionSets[exercise.Name]; !seen {
+			names = append(names, exercise.Name)
+		}
+		sessionSets[exercise.Name] = append(sessionSets[exercise.Name], exercise.Sets...)
+	}
+
+	for _, name := range names {
+		rows, err := tx.Query(`
+			SELECT e.workout_id, s.weight, s.reps
+			FROM sets s
+			JOIN exercises e ON s.exercise_id = e.id
+			JOIN workouts w ON e.workout_id = w.id
+			WHERE e.name = ? AND w.id != ? AND w.date <= ?
+		`, name, workoutID, workout.Date)
+		if err != nil {
+			return nil, err
+		}
+
+		var history []Set
+		historyVolume := make(map[int]float64)
+		for rows.Next() {
+			var histWorkoutID int
+			var set Set
+			if err := rows.Scan(&histWorkoutID, &set.Weight, &set.Reps); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			history = append(history, set)
+			historyVolume[histWorkoutID] += set.Weight * float64(set.Reps)
+		}
+		rows.Close()
+		if len(history) == 0 {
+			continue
+		}
+
+		newRecord := func(recordType string, reps int, value, previous float64) {
+			records = append(records, PersonalRecord{
+				WorkoutID:     workoutID,
+				ExerciseName:  name,
+				RecordType:    recordType,
+				Reps:          reps,
+				Value:         value,
+				PreviousValue: previous,
+				Date:          workout.Date,
+			})
+		}
+
+		// Rep-max PRs: heaviest weight for each rep count in this session,
+		// compared with the heaviest weight ever moved for at least that many reps
+		bestAtReps := make(map[int]float64)
+		var repCounts []int
+		for _, set := range sessionSets[name] {
+			if set.Reps < 1 || set.Reps > maxRepMaxReps || set.Weight <= 0 {
+				continue
+			}
+			if _, seen := bestAtReps[set.Reps]; !seen {
+				repCounts = append(repCounts, set.Reps)
+			}
+			if set.Weight > bestAtReps[set.Reps] {
+				bestAtReps[set.Reps] = set.Weight
+			}
+		}
+		sort.Ints(repCounts)
+		for _, reps := range repCounts {
+			var previous float64
+			for _, set := range history {
+				if set.Reps >= reps && set.Weight > previous {
+					previous = set.Weight
+				}
+			}
+			if previous > 0 && bestAtReps[reps] > previous {
+				newRecord("rep_max", reps, bestAtReps[reps], previous)
+			}
+		}
+
+		// e1RM PR
+		var sessionE1RM, previousE1RM float64
+		for _, set := range sessionSets[name] {
+			if set.Reps > 0 && set.Weight > 0 {
+				sessionE1RM = math.Max(sessionE1RM, estimate1RM(set.Weight, set.Reps, formula))
+			}
+		}
+		for _, set := range history {
+			if set.Reps > 0 && set.Weight > 0 {
+				previousE1RM = math.Max(previousE1RM, estimate1RM(set.Weight, set.Reps, formula))
+			}
+		}
+		if previousE1RM > 0 && sessionE1RM > previousE1RM {
+			newRecord("e1rm", 0, sessionE1RM, previousE1RM)
+		}
+
+		// Volume PR: total weight x reps for the exercise in one session
+		var sessionVolume, previousVolume float64
+		for _, set := range sessionSets[name] {
+			sessionVolume += set.Weight * float64(set.Reps)
+		}
+		for _, v := range historyVolume {
+			previousVolume = math.Max(previousVolume, v)
+		}
+		if previousVolume > 0 && sessionVolume > previousVolume {
+			newRecord("volume", 0, sessionVolume, previousVolume)
+		}
+	}
+
+	for i := range records {
+		rec := &records[i]
+		result, err := tx.Exec(`
+			INSERT INTO personal_records (workout_id, exercise_name, record_type, reps, value, previous_value, date)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, rec.WorkoutID, rec.ExerciseName, rec.RecordType, rec.Reps, rec.Value, rec.PreviousValue, rec.Date)
+		if err != nil {
+			return nil, err
+		}
+		id, _ := result.LastInsertId()
+		rec.ID = int(id)
+	}
+
+	return records, nil
+}
+
+// getPersonalRecords returns stored PRs, newest first. Empty filters match everything.
+func getPersonalRecords(exerciseName string, workoutID int) ([]PersonalRecord, error) {
+	query := `SELECT id, workout_id, exercise_name, record_type, reps, value, previous_value, date
+		FROM personal_records WHERE 1 = 1`
+	var args []interface{}
+	if exerciseName != "" {
+		query += " AND exercise_name = ?"
+		args = append(args, exerciseName)
+	}
+	if workoutID != 0 {
+		query += " AND workout_id = ?"
+		args = append(args, workoutID)
+	}
+	query += " ORDER BY date DESC, id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []PersonalRecord{}
+	for rows.Next() {
+		var rec PersonalRecord
+		if err := rows.Scan(&rec.ID, &rec.WorkoutID, &rec.ExerciseName, &rec.RecordType,
+			&rec.Reps, &rec.Value, &rec.PreviousValue, &rec.Date); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// getWorkoutsFromDB loads every workout, newest first. Exercises are kept
+// apart by their row ID, so an exercise logged twice in one workout stays two
+// entries with their own sets, in the order they were logged.
+func getWorkoutsFromDB() ([]Workout, error) {
 	rows, err := db.Query(`
-		SELECT w.id, w.date, w.workout_type, w.workout_day, e.id, e.name, s.reps, s.weight
+		SELECT w.id, w.date, w.workout_type, w.workout_day, w.is_deload, e.id, e.name, e.slot, e.tier, e.stage, s.reps, s.weight
 		FROM workouts w
 		LEFT JOIN exercises e ON w.id = e.workout_id
 		LEFT JOIN sets s ON e.id = s.exercise_id
-		ORDER BY w.date DESC, e.id, s.id
+		ORDER BY w.date DESC, w.id DESC, e.id, s.id
 	`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	workoutMap := make(map[int]*Workout)
-	exerciseMap := make(map[int]*Exercise)
+	workouts := []Workout{}
+	workoutIndex := make(map[int]int)  // workout ID -> index in workouts
+	exerciseIndex := make(map[int]int) // exercise ID -> index in its workout's exercises
 
 	for rows.Next() {
-		var workoutID, exerciseID, workoutDay int
-		var date, exerciseName, workoutType string
-		var reps int
-		var weight float64
+		var workout Workout
+		var exerciseID, reps sql.NullInt64
+		var exerciseName, slot, tier, stage sql.NullString
+		var weight sql.NullFloat64
 
-		err := rows.Scan(&workoutID, &date, &workoutType, &workoutDay, &exerciseID, &exerciseName, &reps, &weight)
+		err := rows.Scan(&workout.ID, &workout.Date, &workout.WorkoutType, &workout.WorkoutDay, &workout.IsDeload,
+			&exerciseID, &exerciseName, &slot, &tier, &stage, &reps, &weight)
 		if err != nil {
 			return nil, err
 		}
 
 		// Create or get workout
-		if _, exists := workoutMap[workoutID]; !exists {
-			workoutMap[workoutID] = &Workout{
-				ID:          workoutID,
-				Date:        date,
-				WorkoutType: workoutType,
-				WorkoutDay:  workoutDay,
-				Exercises:   []Exercise{},
-			}
+		wi, exists := workoutIndex[workout.ID]
+		if !exists {
+			workout.Exercises = []Exercise{}
+			workouts = append(workouts, workout)
+			wi = len(workouts) - 1
+			workoutIndex[workout.ID] = wi
+		}
+		if !exerciseID.Valid {
+			continue // workout without exercises
 		}
 
 		// Create or get exercise
-		if _, exists := exerciseMap[exerciseID]; !exists {
-			exercise := Exercise{
-				Name: exerciseName,
-				Sets: []Set{},
-			}
-			exerciseMap[exerciseID] = &exercise
-			workoutMap[workoutID].Exercises = append(workoutMap[workoutID].Exercises, exercise)
+		id := int(exerciseID.Int64)
+		ei, exists := exerciseIndex[id]
+		if !exists {
+			workouts[wi].Exercises = append(workouts[wi].Exercises, Exercise{
+				ID:    id,
+				Name:  exerciseName.String,
+				Slot:  slot.String,
+				Tier:  tier.String,
+				Stage: stage.String,
+				Sets:  []Set{},
+			})
+			ei = len(workouts[wi].Exercises) - 1
+			exerciseIndex[id] = ei
+		}
+		if reps.Valid {
+			exercise := &workouts[wi].Exercises[ei]
+			exercise.Sets = append(exercise.Sets, Set{Reps: int(reps.Int64), Weight: weight.Float64})
 		}
+	}
+
+	return workouts, rows.Err()
+}
+
+// getWorkoutByID loads a single workout with its exercises and sets in logged order.
+func getWorkoutByID(id int) (Workout, error) {
+	var workout Workout
+	err := db.QueryRow("SELECT id, date, workout_type, workout_day, is_deload FROM workouts WHERE id = ?", id).
+		Scan(&workout.ID, &workout.Date, &workout.WorkoutType, &workout.WorkoutDay, &workout.IsDeload)
+	if err != nil {
+		return workout, err
+	}
 
-		// Add set to exercise
-		set := Set{
-			Reps:   reps,
-			Weight: weight,
+	rows, err := db.Query(`
+		SELECT e.id, e.name, e.slot, e.tier, e.stage, s.reps, s.weight
+		FROM exercises e
+		LEFT JOIN sets s ON s.exercise_id = e.id
+		WHERE e.workout_id = ?
+		ORDER BY e.id, s.id
+	`, id)
+	if err != nil {
+		return workout, err
+	}
+	defer rows.Close()
+
+	workout.Exercises = []Exercise{}
+	lastExerciseID := 0
+	for rows.Next() {
+		var exerciseID int
+		var name, slot, tier, stage string
+		var reps sql.NullInt64
+		var weight sql.NullFloat64
+		if err := rows.Scan(&exerciseID, &name, &slot, &tier, &stage, &reps, &weight); err != nil {
+			return workout, err
 		}
-		// Find the exercise in the workout and add the set
-		for i := range workoutMap[workoutID].Exercises {
-			if workoutMap[workoutID].Exercises[i].Name == exerciseName {
-				workoutMap[workoutID].Exercises[i].Sets = append(workoutMap[workoutID].Exercises[i].Sets, set)
-				break
-			}
+		if exerciseID != lastExerciseID {
+			workout.Exercises = append(workout.Exercises, Exercise{ID: exerciseID, Name: name, Slot: slot, Tier: tier, Stage: stage, Sets: []Set{}})
+			lastExerciseID = exerciseID
+		}
+		if reps.Valid {
+			exercise := &workout.Exercises[len(workout.Exercises)-1]
+			exercise.Sets = append(exercise.Sets, Set{Reps: int(reps.Int64), Weight: weight.Float64})
 		}
 	}
+	return workout, nil
+}
 
-	// Convert map to slice
-	var workouts []Workout
-	for _, workout := range workoutMap {
-		workouts = append(workouts, *workout)
+// repeatExercises copies a workout's exercises for a new session, adding
+// each exercise's increment in kg to its loaded sets. Bodyweight sets
+// (0 kg) stay unloaded.
+func repeatExercises(workout Workout, increments map[string]float64) []Exercise {
+	exercises := []Exercise{}
+	for _, exercise := range workout.Exercises {
+		repeated := Exercise{Name: exercise.Name, Sets: []Set{}}
+		increment := increments[exercise.Name]
+		for _, set := range exercise.Sets {
+			if set.Weight > 0 && increment > 0 {
+				set.Weight += increment
+			}
+			repeated.Sets = append(repeated.Sets, set)
+		}
+		exercises = append(exercises, repeated)
 	}
-
-	return workouts, nil
+	return exercises
 }
 
 func listWorkouts(w http.ResponseWriter, r *http.Request) {
@@ -497,9 +1568,11 @@ func listWorkouts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := struct {
-		Workouts []Workout
+		Workouts        []Workout
+		RepeatIncrement float64
 	}{
-		Workouts: workouts,
+		Workouts:        workouts,
+		RepeatIncrement: getSettings().RepeatIncrement,
 	}
 
 	log.Printf("Number of workouts: %d", len(workouts))
@@ -516,6 +1589,13 @@ func getLatestExercise(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Exercise name required", http.StatusBadRequest)
 		return
 	}
+	// With ?tier= only sets logged in that GZCLP tier count, so a T2 3x10
+	// doesn't prefill a T1 5x3 of the same lift
+	tier := r.URL.Query().Get("tier")
+	if !contains(gzclpTiers, tier) {
+		http.Error(w, "Unknown tier", http.StatusBadRequest)
+		return
+	}
 
 	// Query for the latest exercise data from the most recent workout
 	rows, err := db.Query(`
@@ -523,16 +1603,16 @@ func getLatestExercise(w http.ResponseWriter, r *http.Request) {
 		FROM sets s
 		JOIN exercises e ON s.exercise_id = e.id
 		JOIN workouts w ON e.workout_id = w.id
-		WHERE e.name = ? AND w.id = (
+		WHERE e.name = ? AND (? = '' OR e.tier = ?) AND w.id = (
 			SELECT w2.id
 			FROM workouts w2
 			JOIN exercises e2 ON w2.id = e2.workout_id
-			WHERE e2.name = ?
+			WHERE e2.name = ? AND (? = '' OR e2.tier = ?)
 			ORDER BY w2.date DESC
 			LIMIT 1
 		)
 		ORDER BY s.id
-	`, exerciseName, exerciseName)
+	`, exerciseName, tier, tier, exerciseName, tier, tier)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
@@ -556,12 +1636,30 @@ func getLatestExercise(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Fprintf(w, `{"sets": [`)
+	// With ?deload=true each set also carries the reduced deload weight.
+	// Loaded sets suggest the next weight one progression increment up.
+	deload, _ := strconv.ParseBool(r.URL.Query().Get("deload"))
+	deloadPercent := getSettings().DeloadPercent
+	increments, err := getExerciseIncrements()
+	if err != nil {
+		log.Printf("Error loading progression increments: %v", err)
+	}
+	increment := incrementFor(increments, exerciseName)
+
+	fmt.Fprintf(w, `{"increment": %g, "sets": [`, increment)
 	for i, set := range sets {
 		if i > 0 {
 			fmt.Fprintf(w, `,`)
 		}
-		fmt.Fprintf(w, `{"reps": %d, "weight": %.1f}`, set.Reps, set.Weight)
+		next := set.Weight
+		if set.Weight > 0 {
+			next += increment
+		}
+		if deload {
+			fmt.Fprintf(w, `{"reps": %d, "weight": %g, "next_weight": %g, "deload_weight": %g}`, set.Reps, set.Weight, next, deloadWeight(set.Weight, deloadPercent, increment))
+		} else {
+			fmt.Fprintf(w, `{"reps": %d, "weight": %g, "next_weight": %g}`, set.Reps, set.Weight, next)
+		}
 	}
 	fmt.Fprintf(w, `]}`)
 }
@@ -584,32 +1682,42 @@ func getNextGZCLPWorkoutDay() (int, error) {
 	return currentDay, nil
 }
 
-func getGZCLPExercises(workoutDay int) (string, string, string, string, string) {
-	rows, err := db.Query("SELECT slot, exercise_name FROM gzclp_day_exercises WHERE day = ?", workoutDay)
+// getGZCLPDaySlots returns the configured slots of a day in order, falling
+// back to the default layout for days that have none.
+func getGZCLPDaySlots(day int) []GZCLPDayExercise {
+	rows, err := db.Query(`SELECT day, slot, exercise_name, position, tier, sets, reps, percent FROM gzclp_day_exercises
+		WHERE day = ? ORDER BY position, id`, day)
 	if err != nil {
 		log.Printf("Error querying GZCLP day exercises: %v", err)
-		return "Squat", "Bench Press", "Lat Pulldown", "Leg Press", "Chest Fly"
+		return defaultGZCLPSlots(day)
 	}
 	defer rows.Close()
 
-	slotMap := make(map[string]string)
+	var slots []GZCLPDayExercise
 	for rows.Next() {
-		var slot, name string
-		if err := rows.Scan(&slot, &name); err != nil {
+		var a GZCLPDayExercise
+		if err := rows.Scan(&a.Day, &a.Slot, &a.ExerciseName, &a.Position, &a.Tier, &a.TargetSets, &a.TargetReps, &a.Percent); err != nil {
 			continue
 		}
-		slotMap[slot] = name
+		slots = append(slots, a)
 	}
 
-	if len(slotMap) == 0 {
-		return "Squat", "Bench Press", "Lat Pulldown", "Leg Press", "Chest Fly"
+	if len(slots) == 0 {
+		return defaultGZCLPSlots(day)
 	}
+	return slots
+}
 
-	return slotMap["T1"], slotMap["T2"], slotMap["T3"], slotMap["Additional1"], slotMap["Additional2"]
+func slotExerciseNames(slots []GZCLPDayExercise) []string {
+	names := make([]string, 0, len(slots))
+	for _, slot := range slots {
+		names = append(names, slot.ExerciseName)
+	}
+	return names
 }
 
 func getGZCLPAllDayExercises() ([]GZCLPDayExercise, error) {
-	rows, err := db.Query("SELECT day, slot, exercise_name FROM gzclp_day_exercises ORDER BY day, slot")
+	rows, err := db.Query("SELECT day, slot, exercise_name, position, tier, sets, reps, percent FROM gzclp_day_exercises ORDER BY day, position, id")
 	if err != nil {
 		return nil, err
 	}
@@ -618,7 +1726,7 @@ func getGZCLPAllDayExercises() ([]GZCLPDayExercise, error) {
 	var assignments []GZCLPDayExercise
 	for rows.Next() {
 		var a GZCLPDayExercise
-		if err := rows.Scan(&a.Day, &a.Slot, &a.ExerciseName); err != nil {
+		if err := rows.Scan(&a.Day, &a.Slot, &a.ExerciseName, &a.Position, &a.Tier, &a.TargetSets, &a.TargetReps, &a.Percent); err != nil {
 			continue
 		}
 		assignments = append(assignments, a)
@@ -626,37 +1734,223 @@ func getGZCLPAllDayExercises() ([]GZCLPDayExercise, error) {
 	return assignments, nil
 }
 
+func getGZCLPConfig() (GZCLPConfig, error) {
+	config := GZCLPConfig{Days: getGZCLPRotationDays(db)}
+	slots, err := getGZCLPAllDayExercises()
+	if err != nil {
+		return config, err
+	}
+	config.Slots = slots
+	if config.Slots == nil {
+		config.Slots = []GZCLPDayExercise{}
+	}
+	return config, nil
+}
+
+// getGZCLPRotationDays is the number of days in the GZCLP rotation.
+func getGZCLPRotationDays(q queryRower) int {
+	var days int
+	if err := q.QueryRow("SELECT rotation_days FROM gzclp_settings WHERE id = 1").Scan(&days); err != nil || days < 1 {
+		return defaultGZCLPDays
+	}
+	return days
+}
+
+// validateGZCLPConfig checks a full config and fills in slot positions from
+// the order slots are listed in, and tiers from legacy slot names.
+func validateGZCLPConfig(config *GZCLPConfig) error {
+	if config.Days < 1 || config.Days > maxGZCLPDays {
+		return fmt.Errorf("days must be between 1 and %d", maxGZCLPDays)
+	}
+	seen := map[string]bool{}
+	positions := map[int]int{}
+	for i := range config.Slots {
+		slot := &config.Slots[i]
+		slot.Slot = strings.TrimSpace(slot.Slot)
+		slot.ExerciseName = strings.TrimSpace(slot.ExerciseName)
+		if slot.Day < 1 || slot.Day > config.Days {
+			return fmt.Errorf("slot %q is on day %d, outside the %d-day rotation", slot.Slot, slot.Day, config.Days)
+		}
+		if slot.Slot == "" || slot.ExerciseName == "" {
+			return fmt.Errorf("each slot needs a name and an exercise")
+		}
+		key := fmt.Sprintf("%d/%s", slot.Day, slot.Slot)
+		if seen[key] {
+			return fmt.Errorf("slot %q is listed twice on day %d", slot.Slot, slot.Day)
+		}
+		seen[key] = true
+		if slot.Tier == "" {
+			slot.Tier = slotTier(slot.Slot)
+		}
+		if !contains(gzclpTiers, slot.Tier) {
+			return fmt.Errorf("tier must be T1, T2, T3 or empty")
+		}
+		if err := validateSlotPrescription(*slot); err != nil {
+			return err
+		}
+		slot.Position = positions[slot.Day]
+		positions[slot.Day]++
+	}
+	return nil
+}
+
+// validateSlotPrescription checks the optional sets, reps and percent of a
+// slot. Zero values fall back to the tier defaults.
+func validateSlotPrescription(slot GZCLPDayExercise) error {
+	if slot.TargetSets < 0 || slot.TargetSets > maxTemplateSets {
+		return fmt.Errorf("sets must be between 0 and %d", maxTemplateSets)
+	}
+	if slot.TargetReps < 0 {
+		return fmt.Errorf("reps cannot be negative")
+	}
+	if slot.Percent < 0 || slot.Percent > maxTrainingMaxPercent {
+		return fmt.Errorf("percent must be between 0 and %d", maxTrainingMaxPercent)
+	}
+	return nil
+}
+
+// GZCLPFormSlot is a slot as the GZCLP form renders it: T1 starts with five
+// sets of three, T2 with three sets of ten and everything else with three
+// sets of fifteen, unless the slot prescribes its own. Weight is prefilled
+// when the slot is a percentage of a known training max. T1 and T2 lifts
+// without their own prescription follow the stage they are on, and Stage is
+// what they are logged against.
+type GZCLPFormSlot struct {
+	GZCLPDayExercise
+	Index  int
+	Label  string // heading of accessory slots
+	Sets   []int
+	Reps   int
+	Weight float64
+	Stage  string
+}
+
+func gzclpFormSlots(slots []GZCLPDayExercise, trainingMaxes, increments map[string]float64, stages map[string]string) []GZCLPFormSlot {
+	var formSlots []GZCLPFormSlot
+	accessories := 0
+	for i, slot := range slots {
+		sets := 3
+		f := GZCLPFormSlot{GZCLPDayExercise: slot, Index: i, Reps: 15}
+		switch slot.Tier {
+		case "T1":
+			sets, f.Reps = 5, 3
+		case "T2":
+			f.Reps = 10
+		case "":
+			accessories++
+			f.Label = fmt.Sprintf("Optional Exercise %d", accessories)
+		}
+		if stageSets, stageReps, ok := parseGZCLPStage(stages[gzclpLiftKey(slot.Tier, slot.ExerciseName)]); ok {
+			sets, f.Reps = stageSets, stageReps
+		}
+		if slot.TargetSets > 0 {
+			sets = slot.TargetSets
+		}
+		if slot.TargetReps > 0 {
+			f.Reps = slot.TargetReps
+		}
+		for n := 0; n < sets; n++ {
+			f.Sets = append(f.Sets, n)
+		}
+		if slot.Tier == "T1" || slot.Tier == "T2" {
+			f.Stage = fmt.Sprintf("%dx%d", sets, f.Reps)
+		}
+		if tm, ok := trainingMaxes[slot.ExerciseName]; ok && slot.Percent > 0 {
+			f.Weight = percentOfTrainingMax(tm, slot.Percent, incrementFor(increments, slot.ExerciseName))
+		}
+		formSlots = append(formSlots, f)
+	}
+	return formSlots
+}
+
 func gzclpForm(w http.ResponseWriter, r *http.Request) {
 	workoutDay, err := getNextGZCLPWorkoutDay()
 	if err != nil {
 		log.Printf("Error getting workout day: %v", err)
 		workoutDay = 1
 	}
+	state, err := loadGZCLPState(db)
+	if err != nil {
+		log.Printf("Error loading GZCLP state: %v", err)
+		state = GZCLPState{CurrentDay: workoutDay}
+	}
+	nextDay, _ := state.advance(workoutDay)
 
-	t1, t2, t3, additional1, additional2 := getGZCLPExercises(workoutDay)
+	trainingMaxes, err := getCurrentTrainingMaxes()
+	if err != nil {
+		log.Printf("Error loading training maxes: %v", err)
+	}
+	increments, err := getExerciseIncrements()
+	if err != nil {
+		log.Printf("Error loading progression increments: %v", err)
+	}
+	progress, err := getGZCLPProgress()
+	if err != nil {
+		log.Printf("Error checking GZCLP progress: %v", err)
+	}
+	slots := gzclpFormSlots(getGZCLPDaySlots(workoutDay), trainingMaxes, increments, progress.Stages)
+	// Lifts that were just reset start from their restart weight
+	for _, reset := range progress.Resets.Restarts {
+		for i := range slots {
+			if slots[i].Tier == reset.Tier && slots[i].ExerciseName == reset.ExerciseName {
+				slots[i].Weight = reset.NewWeight
+			}
+		}
+	}
+	setCounts := make([]int, len(slots))
+	var focus []string
+	for i, slot := range slots {
+		setCounts[i] = len(slot.Sets)
+		if slot.Tier != "" {
+			focus = append(focus, slot.ExerciseName)
+		}
+	}
+	rotation := make([]int, state.rotationDays())
+	for i := range rotation {
+		rotation[i] = i + 1
+	}
 
 	// Get all exercises
 	exercises, _ := getAllExercises()
 
+	deload, err := getDeloadStatus(time.Now())
+	if err != nil {
+		log.Printf("Error checking deload rules: %v", err)
+	}
+
 	tmpl := template.Must(template.ParseFiles("templates/gzclp_form.html"))
 	data := struct {
-		Today               string
-		WorkoutDay          int
-		T1Exercise          string
-		T2Exercise          string
-		T3Exercise          string
-		Additional1Exercise string
-		Additional2Exercise string
-		Exercises           []ExerciseDB
+		Today          string
+		WorkoutDay     int
+		NextDay        int
+		Upcoming       []int
+		Rotation       []int
+		Focus          string
+		Deload         DeloadStatus
+		Resets         []GZCLPReset
+		Settings       Settings
+		Slots          []GZCLPFormSlot
+		SetCounts      []int
+		Exercises      []ExerciseDB
+		MuscleGroups   []string
+		EquipmentTypes []string
+		FormToken      string
 	}{
-		Today:               time.Now().Format("2006-01-02"),
-		WorkoutDay:          workoutDay,
-		T1Exercise:          t1,
-		T2Exercise:          t2,
-		T3Exercise:          t3,
-		Additional1Exercise: additional1,
-		Additional2Exercise: additional2,
-		Exercises:           exercises,
+		Today:          time.Now().Format("2006-01-02"),
+		WorkoutDay:     workoutDay,
+		NextDay:        nextDay,
+		Upcoming:       state.Upcoming,
+		Rotation:       rotation,
+		Focus:          strings.Join(focus, " + "),
+		Deload:         deload,
+		Resets:         progress.Resets.Pending,
+		Settings:       getSettings(),
+		Slots:          slots,
+		SetCounts:      setCounts,
+		Exercises:      exercises,
+		MuscleGroups:   muscleGroups,
+		EquipmentTypes: equipmentTypes,
+		FormToken:      newFormToken(),
 	}
 	tmpl.Execute(w, data)
 }
@@ -667,23 +1961,64 @@ func skipGZCLPDay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	r.ParseForm()
+	skip := SkippedSession{
+		Date:   r.FormValue("date"),
+		Reason: r.FormValue("reason"),
+		Note:   strings.TrimSpace(r.FormValue("note")),
+	}
+	if skip.Date == "" {
+		skip.Date = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", skip.Date); err != nil {
+		http.Error(w, "Invalid date", http.StatusBadRequest)
+		return
+	}
+	if !contains(skipReasons, skip.Reason) {
+		http.Error(w, "Unknown skip reason", http.StatusBadRequest)
+		return
+	}
+
+	// Look up what each day would have trained before the transaction opens
+	dayExercises := map[int][]string{}
+	for day := 1; day <= getGZCLPRotationDays(db); day++ {
+		dayExercises[day] = slotExerciseNames(getGZCLPDaySlots(day))
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error starting transaction: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
 	// Get current workout day (the day we're about to skip)
-	currentDay, err := getNextGZCLPWorkoutDay()
+	state, err := loadGZCLPState(tx)
 	if err != nil {
 		log.Printf("Error getting current workout day: %v", err)
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
+	currentDay := state.CurrentDay
 
-	// Calculate next workout day (1-4 cycle)
-	nextDay := (currentDay % 4) + 1
+	// Calculate next workout day (the normal cycle, unless days were swapped)
+	nextDay, _ := state.advance(currentDay)
 
-	// Update the current day in settings and increment skipped days counter
-	_, err = db.Exec(`
-		UPDATE gzclp_settings
-		SET current_day = ?, skipped_days = skipped_days + 1
-		WHERE id = 1
-	`, nextDay)
+	// Record the skip, which moves the rotation on and counts the skipped day
+	detail := fmt.Sprintf("Skipped day %d", currentDay)
+	if skip.Reason != "" {
+		detail += " (" + skip.Reason + ")"
+	}
+	skip.EventID, _, err = applyGZCLPEvent(tx, GZCLPEvent{Type: "skip", FromDay: currentDay, ToDay: nextDay, Detail: detail})
+	if err == nil {
+		skip.WorkoutDay = currentDay
+		skip.Exercises = dayExercises[currentDay]
+		err = insertSkippedSession(tx, skip)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
 
 	if err != nil {
 		log.Printf("Error updating GZCLP settings: %v", err)
@@ -724,6 +2059,18 @@ func deleteWorkout(w http.ResponseWriter, r *http.Request) {
 	}
 	defer tx.Rollback()
 
+	var deleted Workout
+	err = tx.QueryRow("SELECT id, workout_type, workout_day, expected_day FROM workouts WHERE id = ?", workoutID).
+		Scan(&deleted.ID, &deleted.WorkoutType, &deleted.WorkoutDay, &deleted.ExpectedDay)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Workout not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error loading workout: %v", err)
+		return
+	}
+
 	// Delete sets first (foreign key constraint)
 	_, err = tx.Exec(`
 		DELETE FROM sets
@@ -737,6 +2084,14 @@ func deleteWorkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Delete PRs set in this workout
+	_, err = tx.Exec("DELETE FROM personal_records WHERE workout_id = ?", workoutID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error deleting personal records: %v", err)
+		return
+	}
+
 	// Delete exercises
 	_, err = tx.Exec("DELETE FROM exercises WHERE workout_id = ?", workoutID)
 	if err != nil {
@@ -765,16 +2120,82 @@ func deleteWorkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = tx.Commit()
-	if err != nil {
+	response := DeleteWorkoutResult{Success: true}
+	if deleted.WorkoutType == "gzclp" {
+		response.GZCLPDay, response.GZCLPReverted, err = rewindGZCLPRotation(tx, deleted)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error rewinding GZCLP rotation: %v", err)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		log.Printf("Error committing transaction: %v", err)
 		return
 	}
 
 	log.Printf("Successfully deleted workout ID: %d", workoutID)
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, "Workout deleted successfully")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type DeleteWorkoutResult struct {
+	Success       bool `json:"success"`
+	GZCLPDay      int  `json:"gzclp_day,omitempty"`      // next GZCLP day after the delete
+	GZCLPReverted bool `json:"gzclp_reverted,omitempty"` // whether the delete moved the rotation back
+}
+
+// rewindGZCLPRotation undoes the advance made by a deleted GZCLP workout.
+// That is only the case when it was the latest GZCLP workout and the
+// rotation still sits where the workout left it; if a later skip already
+// moved the rotation on, it is left alone. Overrides go back to the day the
+// rotation was on before the override, and any days queued by a swap are
+// queued again. It returns the next day and whether it changed.
+func rewindGZCLPRotation(tx *sql.Tx, deleted Workout) (int, bool, error) {
+	events, err := getGZCLPEvents(tx)
+	if err != nil {
+		return 0, false, err
+	}
+	state := deriveGZCLPState(events)
+
+	var newer int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM workouts WHERE workout_type = 'gzclp' AND id > ?", deleted.ID).Scan(&newer); err != nil {
+		return 0, false, err
+	}
+	if newer > 0 {
+		return state.CurrentDay, false, nil
+	}
+
+	day := deleted.WorkoutDay
+	if deleted.ExpectedDay > 0 {
+		day = deleted.ExpectedDay
+	}
+	// Replay the history around the workout's event to see what it
+	// advanced from and to; workouts from before the history just advanced
+	// through the normal rotation
+	before := GZCLPState{CurrentDay: day}
+	after := GZCLPState{Days: getGZCLPRotationDays(tx)}
+	after.CurrentDay, _ = after.advance(deleted.WorkoutDay)
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type == "workout" && events[i].WorkoutID == deleted.ID && !events[i].Undone {
+			before, after = deriveGZCLPState(events[:i]), deriveGZCLPState(events[:i+1])
+			break
+		}
+	}
+	if state.CurrentDay != after.CurrentDay || encodeDays(state.Upcoming) != encodeDays(after.Upcoming) {
+		return state.CurrentDay, false, nil
+	}
+
+	_, _, err = applyGZCLPEvent(tx, GZCLPEvent{Type: "workout_deleted", FromDay: state.CurrentDay, ToDay: day, WorkoutID: deleted.ID,
+		Detail: fmt.Sprintf("Deleted day %d workout", deleted.WorkoutDay), Data: encodeDays(before.Upcoming)})
+	if err != nil {
+		return 0, false, err
+	}
+	log.Printf("Rewound GZCLP from day %d to day %d after deleting workout %d", state.CurrentDay, day, deleted.ID)
+	return day, true, nil
 }
 
 func exercisesPage(w http.ResponseWriter, r *http.Request) {
@@ -784,7 +2205,16 @@ func exercisesPage(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Error parsing exercises template: %v", err)
 		return
 	}
-	tmpl.Execute(w, nil)
+	data := struct {
+		MuscleGroups     []string
+		EquipmentTypes   []string
+		MovementPatterns []string
+	}{
+		MuscleGroups:     muscleGroups,
+		EquipmentTypes:   equipmentTypes,
+		MovementPatterns: movementPatterns,
+	}
+	tmpl.Execute(w, data)
 }
 
 func handleExercisesAPI(w http.ResponseWriter, r *http.Request) {
@@ -797,9 +2227,8 @@ func handleExercisesAPI(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Database error", http.StatusInternalServerError)
 			return
 		}
-		if exercises == nil {
-			exercises = []ExerciseDB{}
-		}
+		q := r.URL.Query()
+		exercises = filterExercises(exercises, q.Get("muscle"), q.Get("equipment"), q.Get("movement_pattern"))
 		json.NewEncoder(w).Encode(exercises)
 
 	case "POST":
@@ -812,13 +2241,24 @@ func handleExercisesAPI(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Name is required", http.StatusBadRequest)
 			return
 		}
-		result, err := db.Exec("INSERT INTO exercise_library (name, is_default) VALUES (?, 0)", exercise.Name)
+		if msg := validateExerciseMetadata(exercise); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		result, err := db.Exec(`INSERT INTO exercise_library
+			(name, is_default, primary_muscles, secondary_muscles, equipment, movement_pattern, increment)
+			VALUES (?, 0, ?, ?, ?, ?, ?)`,
+			exercise.Name, joinList(exercise.PrimaryMuscles), joinList(exercise.SecondaryMuscles),
+			exercise.Equipment, exercise.MovementPattern, exercise.Increment)
 		if err != nil {
 			http.Error(w, "Exercise already exists or database error", http.StatusConflict)
 			return
 		}
 		id, _ := result.LastInsertId()
 		exercise.ID = int(id)
+		exercise.PrimaryMuscles = splitList(joinList(exercise.PrimaryMuscles))
+		exercise.SecondaryMuscles = splitList(joinList(exercise.SecondaryMuscles))
+		exercise.ProgressionIncrement = exerciseIncrement(exercise)
 		json.NewEncoder(w).Encode(exercise)
 
 	case "PUT":
@@ -831,25 +2271,55 @@ func handleExercisesAPI(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "ID and name are required", http.StatusBadRequest)
 			return
 		}
+		if msg := validateExerciseMetadata(exercise); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
 		// Get old name to update references
 		var oldName string
 		var isDefault bool
 		db.QueryRow("SELECT name, is_default FROM exercise_library WHERE id = ?", exercise.ID).Scan(&oldName, &isDefault)
-		if isDefault {
+		// Default exercises can have their metadata edited but not be renamed
+		if isDefault && exercise.Name != oldName {
 			http.Error(w, "Cannot edit default exercises", http.StatusForbidden)
 			return
 		}
+		exercise.IsDefault = isDefault
+
+		tx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
 
-		_, err := db.Exec("UPDATE exercise_library SET name = ? WHERE id = ?",
-			exercise.Name, exercise.ID)
+		_, err = tx.Exec(`UPDATE exercise_library
+			SET name = ?, primary_muscles = ?, secondary_muscles = ?, equipment = ?, movement_pattern = ?, increment = ?
+			WHERE id = ?`,
+			exercise.Name, joinList(exercise.PrimaryMuscles), joinList(exercise.SecondaryMuscles),
+			exercise.Equipment, exercise.MovementPattern, exercise.Increment, exercise.ID)
 		if err != nil {
 			http.Error(w, "Database error", http.StatusInternalServerError)
 			return
 		}
-		// Update GZCLP day assignments if name changed
+		// Update GZCLP day assignments, templates and training maxes if name
+		// changed. Resets stay with the logged exercises they were made for.
 		if oldName != "" && oldName != exercise.Name {
-			db.Exec("UPDATE gzclp_day_exercises SET exercise_name = ? WHERE exercise_name = ?", exercise.Name, oldName)
+			for _, table := range []string{"gzclp_day_exercises", "template_exercises", "training_maxes"} {
+				if _, err := tx.Exec("UPDATE "+table+" SET exercise_name = ? WHERE exercise_name = ?", exercise.Name, oldName); err != nil {
+					http.Error(w, "Database error", http.StatusInternalServerError)
+					log.Printf("Error renaming %s in %s: %v", oldName, table, err)
+					return
+				}
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
 		}
+		exercise.PrimaryMuscles = splitList(joinList(exercise.PrimaryMuscles))
+		exercise.SecondaryMuscles = splitList(joinList(exercise.SecondaryMuscles))
+		exercise.ProgressionIncrement = exerciseIncrement(exercise)
 		json.NewEncoder(w).Encode(exercise)
 
 	case "DELETE":
@@ -887,19 +2357,63 @@ func handleGZCLPConfigAPI(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case "GET":
-		assignments, err := getGZCLPAllDayExercises()
+		config, err := getGZCLPConfig()
 		if err != nil {
 			http.Error(w, "Database error", http.StatusInternalServerError)
 			return
 		}
-		json.NewEncoder(w).Encode(assignments)
+		json.NewEncoder(w).Encode(config)
 
 	case "PUT":
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		// The previous config is kept with the event so it can be undone
+		before, err := getGZCLPConfig()
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		snapshot, _ := json.Marshal(before)
+		state, err := loadGZCLPState(db)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+
+		// A bare list updates the named slots only; a {days, slots} object
+		// replaces the whole layout
 		var assignments []GZCLPDayExercise
-		if err := json.NewDecoder(r.Body).Decode(&assignments); err != nil {
+		var config GZCLPConfig
+		partial := strings.HasPrefix(strings.TrimSpace(string(body)), "[")
+		if partial {
+			err = json.Unmarshal(body, &assignments)
+		} else {
+			err = json.Unmarshal(body, &config)
+		}
+		if err != nil {
 			http.Error(w, "Invalid JSON", http.StatusBadRequest)
 			return
 		}
+		if !partial {
+			if err := validateGZCLPConfig(&config); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		for _, a := range assignments {
+			if a.Day < 1 || a.Day > before.Days {
+				http.Error(w, fmt.Sprintf("day must be between 1 and %d", before.Days), http.StatusBadRequest)
+				return
+			}
+			if err := validateSlotPrescription(a); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
 		tx, err := db.Begin()
 		if err != nil {
 			http.Error(w, "Database error", http.StatusInternalServerError)
@@ -907,14 +2421,33 @@ func handleGZCLPConfigAPI(w http.ResponseWriter, r *http.Request) {
 		}
 		defer tx.Rollback()
 
-		for _, a := range assignments {
-			_, err := tx.Exec(
-				"INSERT OR REPLACE INTO gzclp_day_exercises (day, slot, exercise_name) VALUES (?, ?, ?)",
-				a.Day, a.Slot, a.ExerciseName)
-			if err != nil {
+		event := GZCLPEvent{Type: "config", FromDay: state.CurrentDay, ToDay: state.CurrentDay, Data: string(snapshot)}
+		if partial {
+			for _, a := range assignments {
+				if err := upsertGZCLPSlot(tx, a); err != nil {
+					http.Error(w, "Database error", http.StatusInternalServerError)
+					return
+				}
+			}
+			event.Detail = fmt.Sprintf("Changed %d exercise assignment(s)", len(assignments))
+		} else {
+			if err := replaceGZCLPConfig(tx, config); err != nil {
 				http.Error(w, "Database error", http.StatusInternalServerError)
 				return
 			}
+			event.Detail = fmt.Sprintf("Changed the program layout to %d slot(s)", len(config.Slots))
+			if config.Days != before.Days {
+				// Any swapped order refers to the old rotation, so it is dropped
+				event.Type = "rotation"
+				if event.ToDay > config.Days {
+					event.ToDay = 1
+				}
+				event.Detail = fmt.Sprintf("Changed the rotation from %d to %d days", before.Days, config.Days)
+			}
+		}
+		if _, _, err := applyGZCLPEvent(tx, event); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
 		}
 		if err := tx.Commit(); err != nil {
 			http.Error(w, "Database error", http.StatusInternalServerError)
@@ -927,156 +2460,3292 @@ func handleGZCLPConfigAPI(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func statisticsPage(w http.ResponseWriter, r *http.Request) {
-	tmpl, err := template.ParseFiles("templates/statistics.html")
-	if err != nil {
-		http.Error(w, "Template error", http.StatusInternalServerError)
-		log.Printf("Error parsing statistics template: %v", err)
-		return
+// upsertGZCLPSlot changes the exercise of a slot, adding the slot at the end
+// of its day when it doesn't exist yet. The prescription of an existing slot
+// is only changed by replacing the whole config.
+func upsertGZCLPSlot(tx *sql.Tx, a GZCLPDayExercise) error {
+	if a.Tier == "" {
+		a.Tier = slotTier(a.Slot)
 	}
+	_, err := tx.Exec(`INSERT INTO gzclp_day_exercises (day, slot, exercise_name, position, tier, sets, reps, percent)
+		VALUES (?, ?, ?, (SELECT COALESCE(MAX(position) + 1, 0) FROM gzclp_day_exercises WHERE day = ?), ?, ?, ?, ?)
+		ON CONFLICT(day, slot) DO UPDATE SET exercise_name = excluded.exercise_name`,
+		a.Day, a.Slot, a.ExerciseName, a.Day, a.Tier, a.TargetSets, a.TargetReps, a.Percent)
+	return err
+}
 
-	err = tmpl.Execute(w, nil)
-	if err != nil {
-		http.Error(w, "Template execution error", http.StatusInternalServerError)
-		log.Printf("Error executing statistics template: %v", err)
+// replaceGZCLPConfig swaps in a whole layout. A zero Days keeps the current
+// rotation length.
+func replaceGZCLPConfig(tx *sql.Tx, config GZCLPConfig) error {
+	if _, err := tx.Exec("DELETE FROM gzclp_day_exercises"); err != nil {
+		return err
+	}
+	for _, a := range config.Slots {
+		_, err := tx.Exec(`INSERT INTO gzclp_day_exercises (day, slot, exercise_name, position, tier, sets, reps, percent)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			a.Day, a.Slot, a.ExerciseName, a.Position, a.Tier, a.TargetSets, a.TargetReps, a.Percent)
+		if err != nil {
+			return err
+		}
+	}
+	if config.Days > 0 {
+		if _, err := tx.Exec("UPDATE gzclp_settings SET rotation_days = ? WHERE id = 1", config.Days); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-type StatisticsData struct {
-	Date         string  `json:"date"`
-	Estimated1RM float64 `json:"estimated_1rm"`
-	TotalVolume  float64 `json:"total_volume"`
+// decodeGZCLPConfigSnapshot reads the config saved with a config event.
+// Older events saved a bare list of the five fixed slots.
+func decodeGZCLPConfigSnapshot(data string) (GZCLPConfig, error) {
+	var config GZCLPConfig
+	if !strings.HasPrefix(strings.TrimSpace(data), "[") {
+		err := json.Unmarshal([]byte(data), &config)
+		return config, err
+	}
+	if err := json.Unmarshal([]byte(data), &config.Slots); err != nil {
+		return config, err
+	}
+	legacyPositions := map[string]int{"T1": 0, "T2": 1, "T3": 2, "Additional1": 3, "Additional2": 4}
+	for i := range config.Slots {
+		config.Slots[i].Position = legacyPositions[config.Slots[i].Slot]
+		config.Slots[i].Tier = slotTier(config.Slots[i].Slot)
+	}
+	return config, nil
 }
 
-type StatisticsResponse struct {
-	Exercises []string         `json:"exercises"`
-	Data      []StatisticsData `json:"data"`
+// weightRoundingStep is the smallest plate jump used when the app computes loads.
+const weightRoundingStep = 2.5
+
+// roundWeight rounds a load to the nearest weightRoundingStep.
+func roundWeight(weight float64) float64 {
+	return math.Round(weight/weightRoundingStep) * weightRoundingStep
 }
 
-func calculate1RM(weight float64, reps int) float64 {
-	if reps == 1 {
-		return weight
+// roundWeightFor rounds a load for an exercise with the given progression
+// increment. Exercises loaded with microplates round to their increment.
+func roundWeightFor(weight, increment float64) float64 {
+	if increment > 0 && increment < weightRoundingStep {
+		return math.Round(weight/increment) * increment
 	}
-	// Brzycki formula: 1RM = weight * (36 / (37 - reps))
-	return weight * (36 / (37 - float64(reps)))
+	return roundWeight(weight)
 }
 
-func getStatisticsData(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+// deloadWeight reduces a working weight by percent for a deload session.
+func deloadWeight(weight float64, percent int, increment float64) float64 {
+	return roundWeightFor(weight*float64(100-percent)/100, increment)
+}
 
-	exerciseName := r.URL.Query().Get("exercise")
+type DeloadStatus struct {
+	Due              bool     `json:"due"`
+	Reasons          []string `json:"reasons"`
+	LastDeload       string   `json:"last_deload"`
+	WeeksSinceDeload int      `json:"weeks_since_deload"`
+	FailingLifts     []string `json:"failing_lifts"`
+	Percent          int      `json:"percent"`
+}
 
-	if exerciseName == "" {
-		// Return list of available exercises
-		rows, err := db.Query(`
-			SELECT DISTINCT e.name
-			FROM exercises e
-			JOIN workouts w ON e.workout_id = w.id
-			ORDER BY e.name
-		`)
-		if err != nil {
-			http.Error(w, "Database error", http.StatusInternalServerError)
-			log.Printf("Error querying exercises: %v", err)
-			return
-		}
-		defer rows.Close()
+// validateExerciseTier checks the GZCLP tier and stage a logged exercise
+// carries, returning an error message or "" when they are valid.
+func validateExerciseTier(exercise Exercise) string {
+	if !contains(gzclpTiers, exercise.Tier) {
+		return "Unknown tier " + exercise.Tier
+	}
+	if _, _, ok := parseGZCLPStage(exercise.Stage); exercise.Stage != "" && !ok {
+		return "Invalid stage " + exercise.Stage
+	}
+	return ""
+}
 
-		var exercises []string
-		for rows.Next() {
-			var name string
-			if err := rows.Scan(&name); err != nil {
-				continue
-			}
-			exercises = append(exercises, name)
+// parseGZCLPStage splits a stage such as "5x3" into its sets and reps.
+func parseGZCLPStage(stage string) (sets, reps int, ok bool) {
+	parts := strings.Split(stage, "x")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	sets, err := strconv.Atoi(parts[0])
+	if err != nil || sets <= 0 {
+		return 0, 0, false
+	}
+	reps, err = strconv.Atoi(parts[1])
+	if err != nil || reps <= 0 {
+		return 0, 0, false
+	}
+	return sets, reps, true
+}
+
+// gzclpStage guesses the stage of a T1 or T2 session logged before stages
+// were recorded: T1 stages follow the set count (5x3, 6x2, 10x1) and T2
+// stages the most reps done in a set (3x10, 3x8, 3x6).
+func gzclpStage(tier string, reps []int) string {
+	if tier == "T1" {
+		switch {
+		case len(reps) >= 10:
+			return "10x1"
+		case len(reps) >= 6:
+			return "6x2"
+		default:
+			return "5x3"
 		}
+	}
+	most := 0
+	for _, r := range reps {
+		if r > most {
+			most = r
+		}
+	}
+	switch {
+	case most >= 10:
+		return "3x10"
+	case most >= 8:
+		return "3x8"
+	default:
+		return "3x6"
+	}
+}
 
-		response := StatisticsResponse{
-			Exercises: exercises,
-			Data:      []StatisticsData{},
+// gzclpSessionFailed reports whether a T1 or T2 lift missed the sets or reps
+// of the stage it was prescribed. The last T1 set is an AMRAP, but it still
+// has to hit the target.
+func gzclpSessionFailed(stage string, reps []int) bool {
+	sets, target, ok := parseGZCLPStage(stage)
+	if !ok {
+		return false
+	}
+	if len(reps) < sets {
+		return true
+	}
+	for _, r := range reps {
+		if r < target {
+			return true
 		}
+	}
+	return false
+}
 
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			log.Printf("Error encoding JSON response: %v", err)
+// GZCLPLiftSession is one T1 or T2 lift in a logged, non-deload GZCLP
+// workout. Weight is the heaviest set.
+type GZCLPLiftSession struct {
+	WorkoutID int
+	Date      string
+	Name      string
+	Tier      string
+	Stage     string
+	Reps      []int
+	Weight    float64
+}
+
+// getGZCLPLiftSessions returns the T1 and T2 lifts of GZCLP workouts after
+// since (all of them when empty), oldest first. Exercises logged without a
+// tier take the tier they have on that day now, and those logged without a
+// stage have it guessed from their sets.
+func getGZCLPLiftSessions(since string) ([]*GZCLPLiftSession, error) {
+	assignments, err := getGZCLPAllDayExercises()
+	if err != nil {
+		return nil, err
+	}
+	tiers := make(map[int]map[string]string) // day -> exercise -> tier
+	for _, a := range assignments {
+		if a.Tier != "T1" && a.Tier != "T2" {
+			continue
 		}
-		return
+		if tiers[a.Day] == nil {
+			tiers[a.Day] = make(map[string]string)
+		}
+		tiers[a.Day][a.ExerciseName] = a.Tier
 	}
 
-	// Get statistics for specific exercise
 	rows, err := db.Query(`
-		SELECT w.date, s.weight, s.reps
-		FROM sets s
-		JOIN exercises e ON s.exercise_id = e.id
-		JOIN workouts w ON e.workout_id = w.id
-		WHERE e.name = ?
-		ORDER BY w.date, s.weight DESC, s.reps DESC
-	`, exerciseName)
+		SELECT w.id, w.date, w.workout_day, e.id, e.name, e.tier, e.stage, s.reps, s.weight
+		FROM workouts w
+		JOIN exercises e ON e.workout_id = w.id
+		JOIN sets s ON s.exercise_id = e.id
+		WHERE w.workout_type = 'gzclp' AND w.is_deload = 0 AND w.date > ?
+		ORDER BY w.date, w.id, e.id, s.id
+	`, since)
 	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		log.Printf("Error querying exercise statistics: %v", err)
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
-	// Group by date and calculate both best 1RM and total volume per workout
-	type WorkoutData struct {
-		best1RM     float64
-		totalVolume float64
-	}
-	dateMap := make(map[string]*WorkoutData)
-
+	var sessions []*GZCLPLiftSession
+	byExercise := make(map[int]*GZCLPLiftSession)
 	for rows.Next() {
-		var date string
+		var workoutID, day, exerciseID, reps int
+		var date, name, tier, stage string
 		var weight float64
-		var reps int
-
-		if err := rows.Scan(&date, &weight, &reps); err != nil {
+		if err := rows.Scan(&workoutID, &date, &day, &exerciseID, &name, &tier, &stage, &reps, &weight); err != nil {
+			return nil, err
+		}
+		if tier == "" {
+			tier = tiers[day][name]
+		}
+		if tier != "T1" && tier != "T2" {
 			continue
 		}
+		session, exists := byExercise[exerciseID]
+		if !exists {
+			session = &GZCLPLiftSession{WorkoutID: workoutID, Date: date, Name: name, Tier: tier, Stage: stage}
+			byExercise[exerciseID] = session
+			sessions = append(sessions, session)
+		}
+		session.Reps = append(session.Reps, reps)
+		session.Weight = math.Max(session.Weight, weight)
+	}
+	for _, session := range sessions {
+		if session.Stage == "" {
+			session.Stage = gzclpStage(session.Tier, session.Reps)
+		}
+	}
+	return sessions, rows.Err()
+}
 
-		estimated1RM := calculate1RM(weight, reps)
-		volume := weight * float64(reps)
+// getDeloadStatus applies the configured deload rules as of today: a deload
+// is due once DeloadEveryWeeks have passed since the last deload (or the first
+// workout), or once DeloadFailureLifts different T1/T2 lifts have each failed
+// DeloadFailureCount GZCLP sessions since the last deload.
+func getDeloadStatus(today time.Time) (DeloadStatus, error) {
+	settings := getSettings()
+	status := DeloadStatus{Reasons: []string{}, FailingLifts: []string{}, Percent: settings.DeloadPercent}
 
-		if workoutData, exists := dateMap[date]; exists {
-			// Update best 1RM if this is higher
-			if estimated1RM > workoutData.best1RM {
-				workoutData.best1RM = estimated1RM
-			}
-			// Add to total volume
-			workoutData.totalVolume += volume
-		} else {
-			// First set for this date
-			dateMap[date] = &WorkoutData{
-				best1RM:     estimated1RM,
-				totalVolume: volume,
-			}
-		}
+	var lastDeload, firstWorkout sql.NullString
+	err := db.QueryRow("SELECT MAX(date) FROM workouts WHERE is_deload = 1").Scan(&lastDeload)
+	if err != nil {
+		return status, err
 	}
+	if err := db.QueryRow("SELECT MIN(date) FROM workouts").Scan(&firstWorkout); err != nil {
+		return status, err
+	}
+	if !firstWorkout.Valid {
+		return status, nil
+	}
+	status.LastDeload = lastDeload.String
 
-	// Convert map to sorted slice
-	data := []StatisticsData{}
-	for date, workoutData := range dateMap {
-		data = append(data, StatisticsData{
-			Date:         date,
-			Estimated1RM: workoutData.best1RM,
-			TotalVolume:  workoutData.totalVolume,
-		})
+	since := firstWorkout.String
+	if lastDeload.Valid {
+		since = lastDeload.String
+	}
+	if start, err := time.Parse("2006-01-02", since); err == nil {
+		midnight, _ := time.Parse("2006-01-02", today.Format("2006-01-02"))
+		status.WeeksSinceDeload = int(midnight.Sub(start).Hours() / 24 / 7)
+	}
+	if settings.DeloadEveryWeeks > 0 && status.WeeksSinceDeload >= settings.DeloadEveryWeeks {
+		status.Due = true
+		status.Reasons = append(status.Reasons, fmt.Sprintf("%d weeks since last deload", status.WeeksSinceDeload))
 	}
 
-	// Sort by date
-	for i := 0; i < len(data)-1; i++ {
-		for j := i + 1; j < len(data); j++ {
-			if data[i].Date > data[j].Date {
-				data[i], data[j] = data[j], data[i]
-			}
+	if settings.DeloadFailureCount == 0 || settings.DeloadFailureLifts == 0 {
+		return status, nil
+	}
+
+	sessions, err := getGZCLPLiftSessions(lastDeload.String)
+	if err != nil {
+		return status, err
+	}
+
+	failures := make(map[string]int)
+	for _, session := range sessions {
+		if gzclpSessionFailed(session.Stage, session.Reps) {
+			failures[session.Name]++
 		}
 	}
+	for name, count := range failures {
+		if count >= settings.DeloadFailureCount {
+			status.FailingLifts = append(status.FailingLifts, name)
+		}
+	}
+	sort.Strings(status.FailingLifts)
+	if len(status.FailingLifts) >= settings.DeloadFailureLifts {
+		status.Due = true
+		status.Reasons = append(status.Reasons, fmt.Sprintf("repeated failures on %s", strings.Join(status.FailingLifts, ", ")))
+	}
 
-	response := StatisticsResponse{
-		Exercises: []string{},
-		Data:      data,
+	return status, nil
+}
+
+func getDeloadStatusAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	status, err := getDeloadStatus(time.Now())
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error checking deload rules: %v", err)
+		return
 	}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
+	if err := json.NewEncoder(w).Encode(status); err != nil {
 		log.Printf("Error encoding JSON response: %v", err)
 	}
 }
+
+// GZCLP resets. A T1 lift that fails its last stage (10x1+) is retested
+// for a 5RM and restarts at 5x3 with t1ResetPercent of it. A T2 lift that
+// fails 3x6 restarts at 3x10 with its last 3x10 weight plus
+// t2ResetIncrements progression increments, or at t2FallbackResetPercent of
+// the failed 3x6 when it was never logged at 3x10.
+const (
+	t1ResetPercent         = 85
+	t2ResetIncrements      = 2
+	t2FallbackResetPercent = 85
+)
+
+// gzclpTierStages are the stages of each tier in the order a lift moves
+// through them as it fails.
+var gzclpTierStages = map[string][]string{
+	"T1": {"5x3", "6x2", "10x1"},
+	"T2": {"3x10", "3x8", "3x6"},
+}
+
+// gzclpStageIndex is the position of stage among its tier's stages, or -1
+// for a slot's own prescription.
+func gzclpStageIndex(tier, stage string) int {
+	for i, s := range gzclpTierStages[tier] {
+		if s == stage {
+			return i
+		}
+	}
+	return -1
+}
+
+func gzclpLiftKey(tier, exerciseName string) string {
+	return tier + "/" + exerciseName
+}
+
+// GZCLPReset is a reset of one lift after it failed its last stage. Pending
+// resets have no ID yet; for T1 their NewWeight is only known once the 5RM
+// test result is entered. A dismissed reset clears a pending one without
+// restarting the lift.
+type GZCLPReset struct {
+	ID             int     `json:"id"`
+	ExerciseName   string  `json:"exercise_name"`
+	Tier           string  `json:"tier"`
+	WorkoutID      int     `json:"workout_id"` // the failed session
+	FailedDate     string  `json:"failed_date"`
+	FailedStage    string  `json:"failed_stage"`
+	FailedWeight   float64 `json:"failed_weight"`
+	TestWeight     float64 `json:"test_weight,omitempty"` // T1 5RM test result
+	NewWeight      float64 `json:"new_weight"`
+	Dismissed      bool    `json:"dismissed,omitempty"`
+	Date           string  `json:"date,omitempty"`
+	EventID        int     `json:"event_id,omitempty"`
+	AfterWorkoutID int     `json:"-"` // the lift's latest workout when the reset was saved
+}
+
+// GZCLPResetStatus lists the lifts that need a reset, the resets whose
+// restart weight hasn't been lifted yet, and the history of resets.
+type GZCLPResetStatus struct {
+	Pending  []GZCLPReset `json:"pending"`
+	Restarts []GZCLPReset `json:"restarts"`
+	History  []GZCLPReset `json:"history"`
+}
+
+// getGZCLPResets returns resets oldest first, optionally for one exercise.
+func getGZCLPResets(exerciseName string) ([]GZCLPReset, error) {
+	query := `SELECT r.id, r.exercise_name, r.tier, r.workout_id, COALESCE(w.date, ''), r.failed_stage,
+		r.failed_weight, r.test_weight, r.new_weight, r.dismissed, r.date, r.event_id, r.after_workout_id
+		FROM gzclp_resets r LEFT JOIN workouts w ON w.id = r.workout_id`
+	var args []interface{}
+	if exerciseName != "" {
+		query += " WHERE r.exercise_name = ?"
+		args = append(args, exerciseName)
+	}
+	query += " ORDER BY r.date, r.id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resets := []GZCLPReset{}
+	for rows.Next() {
+		var reset GZCLPReset
+		if err := rows.Scan(&reset.ID, &reset.ExerciseName, &reset.Tier, &reset.WorkoutID, &reset.FailedDate, &reset.FailedStage,
+			&reset.FailedWeight, &reset.TestWeight, &reset.NewWeight, &reset.Dismissed, &reset.Date, &reset.EventID,
+			&reset.AfterWorkoutID); err != nil {
+			return nil, err
+		}
+		resets = append(resets, reset)
+	}
+	return resets, rows.Err()
+}
+
+// GZCLPProgress is the program state of the T1 and T2 lifts: the stage each
+// lift is on, keyed by gzclpLiftKey, and its resets.
+type GZCLPProgress struct {
+	Stages map[string]string
+	Resets GZCLPResetStatus
+}
+
+// getGZCLPProgress replays every lift's sessions and resets. A passed
+// session keeps the lift on its stage and a failed one moves it to the next
+// stage; failing the last stage leaves a reset pending until it is saved or
+// dismissed, even if more sessions are logged meanwhile. A saved reset puts
+// the lift back on its first stage, right after the latest session logged
+// before it was saved.
+func getGZCLPProgress() (GZCLPProgress, error) {
+	progress := GZCLPProgress{
+		Stages: map[string]string{},
+		Resets: GZCLPResetStatus{Pending: []GZCLPReset{}, Restarts: []GZCLPReset{}},
+	}
+
+	sessions, err := getGZCLPLiftSessions("")
+	if err != nil {
+		return progress, err
+	}
+	if progress.Resets.History, err = getGZCLPResets(""); err != nil {
+		return progress, err
+	}
+	increments, err := getExerciseIncrements()
+	if err != nil {
+		return progress, err
+	}
+
+	// Each reset is replayed after the last session of its lift it was
+	// saved after; resets saved before any session come first
+	resetsAfter := map[int][]GZCLPReset{}
+	var resetsFirst []GZCLPReset
+	for _, reset := range progress.Resets.History {
+		position := -1
+		for i, session := range sessions {
+			if session.Tier == reset.Tier && session.Name == reset.ExerciseName && session.WorkoutID <= reset.AfterWorkoutID {
+				position = i
+			}
+		}
+		if position < 0 {
+			resetsFirst = append(resetsFirst, reset)
+		} else {
+			resetsAfter[position] = append(resetsAfter[position], reset)
+		}
+	}
+
+	type liftState struct {
+		pending       *GZCLPReset
+		restart       *GZCLPReset
+		latestWorkout int
+		threeByTen    float64 // weight of the latest 3x10 session
+		hasThreeByTen bool
+	}
+	lifts := map[string]*liftState{}
+	var keys []string
+	lift := func(key string) *liftState {
+		if lifts[key] == nil {
+			lifts[key] = &liftState{}
+			keys = append(keys, key)
+		}
+		return lifts[key]
+	}
+	applyReset := func(reset GZCLPReset) {
+		key := gzclpLiftKey(reset.Tier, reset.ExerciseName)
+		state := lift(key)
+		state.pending = nil
+		if !reset.Dismissed {
+			progress.Stages[key] = gzclpTierStages[reset.Tier][0]
+			state.restart = &reset
+		}
+	}
+
+	for _, reset := range resetsFirst {
+		applyReset(reset)
+	}
+	for i, session := range sessions {
+		key := gzclpLiftKey(session.Tier, session.Name)
+		state := lift(key)
+		state.restart = nil
+		state.latestWorkout = session.WorkoutID
+		if session.Stage == "3x10" {
+			state.threeByTen, state.hasThreeByTen = session.Weight, true
+		}
+
+		stages := gzclpTierStages[session.Tier]
+		index := gzclpStageIndex(session.Tier, session.Stage)
+		switch {
+		case index < 0:
+			// A slot's own prescription doesn't move the lift through the stages
+		case !gzclpSessionFailed(session.Stage, session.Reps):
+			progress.Stages[key] = session.Stage
+		case index+1 < len(stages):
+			progress.Stages[key] = stages[index+1]
+		default:
+			progress.Stages[key] = session.Stage
+			reset := GZCLPReset{
+				ExerciseName: session.Name,
+				Tier:         session.Tier,
+				WorkoutID:    session.WorkoutID,
+				FailedDate:   session.Date,
+				FailedStage:  session.Stage,
+				FailedWeight: session.Weight,
+			}
+			if session.Tier == "T2" {
+				increment := incrementFor(increments, session.Name)
+				if state.hasThreeByTen {
+					reset.NewWeight = state.threeByTen + t2ResetIncrements*increment
+				} else {
+					reset.NewWeight = roundWeightFor(session.Weight*t2FallbackResetPercent/100, increment)
+				}
+			}
+			state.pending = &reset
+		}
+
+		for _, reset := range resetsAfter[i] {
+			applyReset(reset)
+		}
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		state := lifts[key]
+		if state.pending != nil {
+			state.pending.AfterWorkoutID = state.latestWorkout
+			progress.Resets.Pending = append(progress.Resets.Pending, *state.pending)
+		}
+		if state.restart != nil {
+			progress.Resets.Restarts = append(progress.Resets.Restarts, *state.restart)
+		}
+	}
+	return progress, nil
+}
+
+// handleGZCLPResetsAPI lists reset status, or records or dismisses a
+// pending reset. T1 resets need the 5RM test result; the restart weight
+// defaults to the program's and can be overridden with new_weight.
+func handleGZCLPResetsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "GET":
+		progress, err := getGZCLPProgress()
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error loading GZCLP resets: %v", err)
+			return
+		}
+		status := progress.Resets
+		if exerciseName := r.URL.Query().Get("exercise"); exerciseName != "" {
+			history := []GZCLPReset{}
+			for _, reset := range status.History {
+				if reset.ExerciseName == exerciseName {
+					history = append(history, reset)
+				}
+			}
+			status.History = history
+		}
+		json.NewEncoder(w).Encode(status)
+
+	case "POST":
+		var input GZCLPReset
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		progress, err := getGZCLPProgress()
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		var reset *GZCLPReset
+		for i, pending := range progress.Resets.Pending {
+			if pending.ExerciseName == input.ExerciseName && pending.Tier == input.Tier {
+				reset = &progress.Resets.Pending[i]
+			}
+		}
+		if reset == nil {
+			http.Error(w, fmt.Sprintf("No reset is pending for %s %s", input.Tier, input.ExerciseName), http.StatusConflict)
+			return
+		}
+		if input.TestWeight < 0 || input.NewWeight < 0 {
+			http.Error(w, "Weights cannot be negative", http.StatusBadRequest)
+			return
+		}
+		reset.Dismissed = input.Dismissed
+		if reset.Dismissed {
+			reset.NewWeight = 0
+		} else if reset.Tier == "T1" {
+			if input.TestWeight == 0 {
+				http.Error(w, "Enter the 5RM test result", http.StatusBadRequest)
+				return
+			}
+			increments, err := getExerciseIncrements()
+			if err != nil {
+				http.Error(w, "Database error", http.StatusInternalServerError)
+				return
+			}
+			reset.TestWeight = input.TestWeight
+			reset.NewWeight = roundWeightFor(input.TestWeight*t1ResetPercent/100, incrementFor(increments, reset.ExerciseName))
+		}
+		if input.NewWeight > 0 && !reset.Dismissed {
+			reset.NewWeight = input.NewWeight
+		}
+		reset.Date = input.Date
+		if reset.Date == "" {
+			reset.Date = time.Now().Format("2006-01-02")
+		}
+		if _, err := time.Parse("2006-01-02", reset.Date); err != nil {
+			http.Error(w, "Invalid date", http.StatusBadRequest)
+			return
+		}
+		state, err := loadGZCLPState(db)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+
+		restart := "3x10"
+		if reset.Tier == "T1" {
+			restart = "5x3"
+		}
+		detail := fmt.Sprintf("Reset %s %s to %s at %g kg after failing %s at %g kg",
+			reset.Tier, reset.ExerciseName, restart, reset.NewWeight, reset.FailedStage, reset.FailedWeight)
+		if reset.TestWeight > 0 {
+			detail += fmt.Sprintf(" (5RM %g kg)", reset.TestWeight)
+		}
+		if reset.Dismissed {
+			detail = fmt.Sprintf("Dismissed the %s %s reset after failing %s at %g kg",
+				reset.Tier, reset.ExerciseName, reset.FailedStage, reset.FailedWeight)
+		}
+		reset.EventID, _, err = applyGZCLPEvent(tx, GZCLPEvent{Type: "reset", FromDay: state.CurrentDay, ToDay: state.CurrentDay,
+			WorkoutID: reset.WorkoutID, Detail: detail})
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		result, err := tx.Exec(`INSERT INTO gzclp_resets
+			(exercise_name, tier, workout_id, failed_stage, failed_weight, test_weight, new_weight, dismissed, date, event_id, after_workout_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			reset.ExerciseName, reset.Tier, reset.WorkoutID, reset.FailedStage, reset.FailedWeight,
+			reset.TestWeight, reset.NewWeight, reset.Dismissed, reset.Date, reset.EventID, reset.AfterWorkoutID)
+		if err != nil {
+			if strings.Contains(err.Error(), "UNIQUE") {
+				http.Error(w, fmt.Sprintf("No reset is pending for %s %s", reset.Tier, reset.ExerciseName), http.StatusConflict)
+				return
+			}
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error saving GZCLP reset: %v", err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		id, _ := result.LastInsertId()
+		reset.ID = int(id)
+		log.Printf("%s", detail)
+		json.NewEncoder(w).Encode(reset)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func statisticsPage(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := template.ParseFiles("templates/statistics.html")
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		log.Printf("Error parsing statistics template: %v", err)
+		return
+	}
+
+	data := struct {
+		Formulas       []string
+		DefaultFormula string
+	}{
+		Formulas:       oneRMFormulas,
+		DefaultFormula: getDefaultOneRMFormula(),
+	}
+
+	err = tmpl.Execute(w, data)
+	if err != nil {
+		http.Error(w, "Template execution error", http.StatusInternalServerError)
+		log.Printf("Error executing statistics template: %v", err)
+	}
+}
+
+type StatisticsData struct {
+	Date         string  `json:"date"`
+	WorkoutID    int     `json:"workout_id,omitempty"`
+	Sessions     int     `json:"sessions"`
+	Deload       bool    `json:"deload"`
+	Estimated1RM float64 `json:"estimated_1rm"`
+	TotalVolume  float64 `json:"total_volume"`
+}
+
+type StatisticsResponse struct {
+	Exercises   []string         `json:"exercises"`
+	Data        []StatisticsData `json:"data"`
+	Formula     string           `json:"formula,omitempty"`
+	Aggregation string           `json:"aggregation,omitempty"`
+	Tier        string           `json:"tier,omitempty"`
+}
+
+// Supported 1RM estimation formulas. "average" is the mean of all the others.
+var oneRMFormulas = []string{"brzycki", "epley", "lombardi", "mayhew", "wathan", "average"}
+
+// maxEstimateReps caps the rep count fed into 1RM formulas. The formulas are
+// fitted on low-rep sets and fall apart beyond this (Brzycki divides by zero
+// at 37 reps), so high-rep AMRAP sets are estimated as if they were this long.
+const maxEstimateReps = 15
+
+// calculate1RM estimates a 1RM with the Brzycki formula.
+func calculate1RM(weight float64, reps int) float64 {
+	return estimate1RM(weight, reps, "brzycki")
+}
+
+// estimate1RM estimates a 1RM from a set using the named formula, falling back
+// to Brzycki for unknown names.
+func estimate1RM(weight float64, reps int, formula string) float64 {
+	if reps <= 0 || weight <= 0 {
+		return 0
+	}
+	if reps == 1 {
+		return weight
+	}
+	if reps > maxEstimateReps {
+		reps = maxEstimateReps
+	}
+	r := float64(reps)
+
+	switch formula {
+	case "epley":
+		return weight * (1 + r/30)
+	case "lombardi":
+		return weight * math.Pow(r, 0.10)
+	case "mayhew":
+		return 100 * weight / (52.2 + 41.9*math.Exp(-0.055*r))
+	case "wathan":
+		return 100 * weight / (48.8 + 53.8*math.Exp(-0.075*r))
+	case "average":
+		var sum float64
+		for _, f := range oneRMFormulas[:len(oneRMFormulas)-1] {
+			sum += estimate1RM(weight, reps, f)
+		}
+		return sum / float64(len(oneRMFormulas)-1)
+	default:
+		return weight * (36 / (37 - r))
+	}
+}
+
+func isValidOneRMFormula(formula string) bool {
+	return contains(oneRMFormulas, formula)
+}
+
+// getSetting returns a stored setting, or fallback if it has never been set.
+func getSetting(key, fallback string) string {
+	var value string
+	if err := db.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value); err != nil {
+		return fallback
+	}
+	return value
+}
+
+func setSetting(key, value string) error {
+	_, err := db.Exec("INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", key, value)
+	return err
+}
+
+func getIntSetting(key string, fallback int) int {
+	value, err := strconv.Atoi(getSetting(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getFloatSetting(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(getSetting(key, ""), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getDefaultOneRMFormula() string {
+	formula := getSetting("one_rm_formula", "brzycki")
+	if !isValidOneRMFormula(formula) {
+		return "brzycki"
+	}
+	return formula
+}
+
+// Settings holds the app-wide preferences. Deload rules are off when their
+// thresholds are zero, and a zero repeat increment repeats workouts with
+// each exercise's own progression increment.
+type Settings struct {
+	OneRMFormula       string  `json:"one_rm_formula"`
+	DeloadEveryWeeks   int     `json:"deload_every_weeks"`
+	DeloadFailureCount int     `json:"deload_failure_count"`
+	DeloadFailureLifts int     `json:"deload_failure_lifts"`
+	DeloadPercent      int     `json:"deload_percent"`
+	RepeatIncrement    float64 `json:"repeat_increment"`
+	SessionsPerWeek    int     `json:"sessions_per_week"`
+}
+
+func getSettings() Settings {
+	return Settings{
+		OneRMFormula:       getDefaultOneRMFormula(),
+		DeloadEveryWeeks:   getIntSetting("deload_every_weeks", 0),
+		DeloadFailureCount: getIntSetting("deload_failure_count", 2),
+		DeloadFailureLifts: getIntSetting("deload_failure_lifts", 2),
+		DeloadPercent:      getIntSetting("deload_percent", 10),
+		RepeatIncrement:    getFloatSetting("repeat_increment", 0),
+		SessionsPerWeek:    getIntSetting("sessions_per_week", 3),
+	}
+}
+
+func validateSettings(settings Settings) error {
+	if !isValidOneRMFormula(settings.OneRMFormula) {
+		return fmt.Errorf("unknown 1RM formula: %s", settings.OneRMFormula)
+	}
+	if settings.DeloadEveryWeeks < 0 || settings.DeloadEveryWeeks > 52 {
+		return fmt.Errorf("deload_every_weeks must be between 0 and 52")
+	}
+	if settings.DeloadFailureCount < 0 || settings.DeloadFailureLifts < 0 {
+		return fmt.Errorf("deload failure thresholds cannot be negative")
+	}
+	if settings.DeloadPercent < 1 || settings.DeloadPercent > 90 {
+		return fmt.Errorf("deload_percent must be between 1 and 90")
+	}
+	if settings.RepeatIncrement < 0 || settings.RepeatIncrement > 50 {
+		return fmt.Errorf("repeat_increment must be between 0 and 50 kg")
+	}
+	if settings.SessionsPerWeek < 1 || settings.SessionsPerWeek > 7 {
+		return fmt.Errorf("sessions_per_week must be between 1 and 7")
+	}
+	return nil
+}
+
+func saveSettings(settings Settings) error {
+	values := map[string]string{
+		"one_rm_formula":       settings.OneRMFormula,
+		"deload_every_weeks":   strconv.Itoa(settings.DeloadEveryWeeks),
+		"deload_failure_count": strconv.Itoa(settings.DeloadFailureCount),
+		"deload_failure_lifts": strconv.Itoa(settings.DeloadFailureLifts),
+		"deload_percent":       strconv.Itoa(settings.DeloadPercent),
+		"repeat_increment":     strconv.FormatFloat(settings.RepeatIncrement, 'f', -1, 64),
+		"sessions_per_week":    strconv.Itoa(settings.SessionsPerWeek),
+	}
+	for key, value := range values {
+		if err := setSetting(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func handleSettingsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "GET":
+		json.NewEncoder(w).Encode(getSettings())
+
+	case "PUT":
+		// Fields missing from the body keep their current values
+		settings := getSettings()
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if err := validateSettings(settings); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := saveSettings(settings); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error saving settings: %v", err)
+			return
+		}
+		json.NewEncoder(w).Encode(getSettings())
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func getStatisticsData(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	exerciseName := r.URL.Query().Get("exercise")
+
+	if exerciseName == "" {
+		// Return list of available exercises
+		rows, err := db.Query(`
+			SELECT DISTINCT e.name
+			FROM exercises e
+			JOIN workouts w ON e.workout_id = w.id
+			ORDER BY e.name
+		`)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error querying exercises: %v", err)
+			return
+		}
+		defer rows.Close()
+
+		var exercises []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				continue
+			}
+			exercises = append(exercises, name)
+		}
+
+		response := StatisticsResponse{
+			Exercises: exercises,
+			Data:      []StatisticsData{},
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Error encoding JSON response: %v", err)
+		}
+		return
+	}
+
+	formula := r.URL.Query().Get("formula")
+	if formula == "" {
+		formula = getDefaultOneRMFormula()
+	} else if !isValidOneRMFormula(formula) {
+		http.Error(w, "Unknown 1RM formula", http.StatusBadRequest)
+		return
+	}
+
+	level := r.URL.Query().Get("aggregate")
+	if level == "" {
+		level = "session"
+	} else if !contains(statisticsAggregationLevels, level) {
+		http.Error(w, "Unknown aggregation level", http.StatusBadRequest)
+		return
+	}
+
+	deload := r.URL.Query().Get("deload")
+	if deload != "" && deload != "exclude" && deload != "only" {
+		http.Error(w, "deload must be exclude or only", http.StatusBadRequest)
+		return
+	}
+
+	tier := r.URL.Query().Get("tier")
+	if !contains(gzclpTiers, tier) {
+		http.Error(w, "Unknown tier", http.StatusBadRequest)
+		return
+	}
+
+	data, err := buildExerciseStatistics(exerciseName, StatisticsFilter{
+		Formula: formula,
+		Level:   level,
+		From:    r.URL.Query().Get("from"),
+		To:      r.URL.Query().Get("to"),
+		Deload:  deload,
+		Tier:    tier,
+	})
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error querying exercise statistics: %v", err)
+		return
+	}
+
+	response := StatisticsResponse{
+		Exercises:   []string{},
+		Data:        data,
+		Formula:     formula,
+		Aggregation: level,
+		Tier:        tier,
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// Statistics can be reported per workout session or rolled up per day, week
+// (starting Monday) or calendar month.
+var statisticsAggregationLevels = []string{"session", "day", "week", "month"}
+
+// statisticsPeriod returns the date a workout is reported under for the given
+// aggregation level: the workout date itself, its Monday, or the 1st of its month.
+func statisticsPeriod(date, level string) (string, error) {
+	switch level {
+	case "week":
+		return weekStart(date)
+	case "month":
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return "", err
+		}
+		return t.Format("2006-01") + "-01", nil
+	default:
+		return date, nil
+	}
+}
+
+// StatisticsFilter selects how exercise statistics are computed. From and To
+// are inclusive dates; Deload is "exclude", "only" or empty for all sessions.
+type StatisticsFilter struct {
+	Formula string
+	Level   string
+	From    string
+	To      string
+	Deload  string
+	Tier    string
+}
+
+// buildExerciseStatistics returns the best estimated 1RM and total volume for
+// an exercise per period, in date order.
+func buildExerciseStatistics(exerciseName string, filter StatisticsFilter) ([]StatisticsData, error) {
+	formula, level := filter.Formula, filter.Level
+	query := `
+		SELECT w.id, w.date, w.is_deload, s.weight, s.reps
+		FROM sets s
+		JOIN exercises e ON s.exercise_id = e.id
+		JOIN workouts w ON e.workout_id = w.id
+		WHERE e.name = ?`
+	args := []interface{}{exerciseName}
+	if filter.Tier != "" {
+		query += " AND e.tier = ?"
+		args = append(args, filter.Tier)
+	}
+	if filter.From != "" {
+		query += " AND w.date >= ?"
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		query += " AND w.date <= ?"
+		args = append(args, filter.To)
+	}
+	switch filter.Deload {
+	case "exclude":
+		query += " AND w.is_deload = 0"
+	case "only":
+		query += " AND w.is_deload = 1"
+	}
+	query += " ORDER BY w.date, w.id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Rows arrive in date order, so periods are appended already sorted
+	data := []StatisticsData{}
+	periodIndex := make(map[string]int)
+	lastWorkout := make(map[string]int)
+
+	for rows.Next() {
+		var workoutID, reps int
+		var date string
+		var isDeload bool
+		var weight float64
+		if err := rows.Scan(&workoutID, &date, &isDeload, &weight, &reps); err != nil {
+			return nil, err
+		}
+
+		key := strconv.Itoa(workoutID)
+		if level != "session" {
+			if key, err = statisticsPeriod(date, level); err != nil {
+				return nil, err
+			}
+		}
+
+		i, exists := periodIndex[key]
+		if !exists {
+			point := StatisticsData{Date: date}
+			if level == "session" {
+				point.WorkoutID = workoutID
+			} else {
+				point.Date = key
+			}
+			data = append(data, point)
+			i = len(data) - 1
+			periodIndex[key] = i
+		}
+
+		point := &data[i]
+		point.Deload = point.Deload || isDeload
+		if lastWorkout[key] != workoutID {
+			point.Sessions++
+			lastWorkout[key] = workoutID
+		}
+		point.Estimated1RM = math.Max(point.Estimated1RM, estimate1RM(weight, reps, formula))
+		point.TotalVolume += weight * float64(reps)
+	}
+
+	return data, nil
+}
+
+// Muscle group volume attribution: a hard set is any logged set with at least
+// one rep (empty 0-rep sets are skipped; bodyweight sets still count). It
+// counts fully toward each primary muscle and as half a set toward each
+// secondary muscle. A set's tonnage is split evenly across its primary
+// muscles so that summing the groups gives the real kilograms lifted.
+const secondaryMuscleSetFactor = 0.5
+
+type MuscleGroupVolume struct {
+	Sets    float64 `json:"sets"`
+	Tonnage float64 `json:"tonnage"`
+}
+
+type WeeklyMuscleGroupVolume struct {
+	WeekStart    string                       `json:"week_start"`
+	MuscleGroups map[string]MuscleGroupVolume `json:"muscle_groups"`
+}
+
+type MuscleGroupReport struct {
+	MuscleGroups []string                  `json:"muscle_groups"`
+	Weeks        []WeeklyMuscleGroupVolume `json:"weeks"`
+	Unclassified []string                  `json:"unclassified"`
+}
+
+// weekStart returns the Monday of the week containing date, both as YYYY-MM-DD.
+func weekStart(date string) (string, error) {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", err
+	}
+	offset := (int(t.Weekday()) + 6) % 7 // Monday = 0
+	return t.AddDate(0, 0, -offset).Format("2006-01-02"), nil
+}
+
+// buildMuscleGroupReport aggregates logged sets per muscle group per week,
+// optionally restricted to workouts dated between from and to (inclusive).
+func buildMuscleGroupReport(from, to string) (MuscleGroupReport, error) {
+	report := MuscleGroupReport{
+		MuscleGroups: muscleGroups,
+		Weeks:        []WeeklyMuscleGroupVolume{},
+		Unclassified: []string{},
+	}
+
+	library, err := getAllExercises()
+	if err != nil {
+		return report, err
+	}
+	metadata := make(map[string]ExerciseDB)
+	for _, e := range library {
+		metadata[e.Name] = e
+	}
+
+	query := `
+		SELECT w.date, e.name, s.weight, s.reps
+		FROM sets s
+		JOIN exercises e ON s.exercise_id = e.id
+		JOIN workouts w ON e.workout_id = w.id
+		WHERE 1 = 1`
+	var args []interface{}
+	if from != "" {
+		query += " AND w.date >= ?"
+		args = append(args, from)
+	}
+	if to != "" {
+		query += " AND w.date <= ?"
+		args = append(args, to)
+	}
+	query += " ORDER BY w.date"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	weekIndex := make(map[string]int)
+	unclassified := make(map[string]bool)
+	for rows.Next() {
+		var date, name string
+		var weight float64
+		var reps int
+		if err := rows.Scan(&date, &name, &weight, &reps); err != nil {
+			return report, err
+		}
+		if reps <= 0 {
+			continue
+		}
+
+		exercise, ok := metadata[name]
+		if !ok || len(exercise.PrimaryMuscles) == 0 {
+			if !unclassified[name] {
+				unclassified[name] = true
+				report.Unclassified = append(report.Unclassified, name)
+			}
+			continue
+		}
+
+		week, err := weekStart(date)
+		if err != nil {
+			continue
+		}
+		idx, exists := weekIndex[week]
+		if !exists {
+			idx = len(report.Weeks)
+			weekIndex[week] = idx
+			report.Weeks = append(report.Weeks, WeeklyMuscleGroupVolume{
+				WeekStart:    week,
+				MuscleGroups: make(map[string]MuscleGroupVolume),
+			})
+		}
+		groups := report.Weeks[idx].MuscleGroups
+
+		tonnage := weight * float64(reps) / float64(len(exercise.PrimaryMuscles))
+		for _, m := range exercise.PrimaryMuscles {
+			v := groups[m]
+			v.Sets++
+			v.Tonnage += tonnage
+			groups[m] = v
+		}
+		for _, m := range exercise.SecondaryMuscles {
+			v := groups[m]
+			v.Sets += secondaryMuscleSetFactor
+			groups[m] = v
+		}
+	}
+
+	return report, nil
+}
+
+func getMuscleGroupStatistics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	report, err := buildMuscleGroupReport(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error building muscle group report: %v", err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+type CalendarDay struct {
+	Date         string   `json:"date"`
+	Workouts     int      `json:"workouts"`
+	Tonnage      float64  `json:"tonnage"`
+	WorkoutTypes []string `json:"workout_types"`
+}
+
+type CalendarResponse struct {
+	From                string        `json:"from"`
+	To                  string        `json:"to"`
+	Days                []CalendarDay `json:"days"`
+	CurrentWeeklyStreak int           `json:"current_weekly_streak"`
+	LongestWeeklyStreak int           `json:"longest_weekly_streak"`
+}
+
+// buildCalendar returns per-day training totals between from and to
+// (inclusive). Only days with at least one workout are listed. Streaks count
+// consecutive Monday-based weeks with a workout over the whole history; the
+// current streak stays alive until a full week passes with no training, so
+// an empty week-to-date doesn't reset it.
+func buildCalendar(from, to string, today time.Time) (CalendarResponse, error) {
+	calendar := CalendarResponse{From: from, To: to, Days: []CalendarDay{}}
+
+	rows, err := db.Query(`
+		SELECT w.id, w.date, w.workout_type, COALESCE(SUM(s.weight * s.reps), 0)
+		FROM workouts w
+		LEFT JOIN exercises e ON e.workout_id = w.id
+		LEFT JOIN sets s ON s.exercise_id = e.id
+		GROUP BY w.id
+		ORDER BY w.date, w.id
+	`)
+	if err != nil {
+		return calendar, err
+	}
+	defer rows.Close()
+
+	trainedWeeks := make(map[string]bool)
+	dayIndex := make(map[string]int)
+	for rows.Next() {
+		var id int
+		var date, workoutType string
+		var tonnage float64
+		if err := rows.Scan(&id, &date, &workoutType, &tonnage); err != nil {
+			return calendar, err
+		}
+
+		if week, err := weekStart(date); err == nil {
+			trainedWeeks[week] = true
+		}
+
+		if date < from || date > to {
+			continue
+		}
+		i, exists := dayIndex[date]
+		if !exists {
+			calendar.Days = append(calendar.Days, CalendarDay{Date: date, WorkoutTypes: []string{}})
+			i = len(calendar.Days) - 1
+			dayIndex[date] = i
+		}
+		day := &calendar.Days[i]
+		day.Workouts++
+		day.Tonnage += tonnage
+		if !contains(day.WorkoutTypes, workoutType) {
+			day.WorkoutTypes = append(day.WorkoutTypes, workoutType)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return calendar, err
+	}
+
+	calendar.CurrentWeeklyStreak, calendar.LongestWeeklyStreak = weeklyStreaks(trainedWeeks, today)
+	return calendar, nil
+}
+
+// weeklyStreaks computes the current and longest runs of consecutive trained
+// weeks, keyed by their Monday date.
+func weeklyStreaks(trainedWeeks map[string]bool, today time.Time) (current, longest int) {
+	weeks := make([]string, 0, len(trainedWeeks))
+	for week := range trainedWeeks {
+		weeks = append(weeks, week)
+	}
+	sort.Strings(weeks)
+
+	run := 0
+	var previous time.Time
+	for _, week := range weeks {
+		t, _ := time.Parse("2006-01-02", week)
+		if run > 0 && t.Sub(previous) == 7*24*time.Hour {
+			run++
+		} else {
+			run = 1
+		}
+		previous = t
+		if run > longest {
+			longest = run
+		}
+	}
+
+	thisWeek, _ := weekStart(today.Format("2006-01-02"))
+	t, _ := time.Parse("2006-01-02", thisWeek)
+	if !trainedWeeks[thisWeek] {
+		t = t.AddDate(0, 0, -7)
+	}
+	for trainedWeeks[t.Format("2006-01-02")] {
+		current++
+		t = t.AddDate(0, 0, -7)
+	}
+	return current, longest
+}
+
+func getCalendarData(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Default to the year ending today; ?year=YYYY selects a calendar year
+	today := time.Now()
+	from := today.AddDate(-1, 0, 1).Format("2006-01-02")
+	to := today.Format("2006-01-02")
+	if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil || year < 1900 || year > 9999 {
+			http.Error(w, "Invalid year", http.StatusBadRequest)
+			return
+		}
+		from = fmt.Sprintf("%04d-01-01", year)
+		to = fmt.Sprintf("%04d-12-31", year)
+	}
+
+	calendar, err := buildCalendar(from, to, today)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error building training calendar: %v", err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(calendar); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+func getMeasurementTypes() ([]MeasurementType, error) {
+	rows, err := db.Query("SELECT id, name, unit, is_default FROM measurement_types ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := []MeasurementType{}
+	for rows.Next() {
+		var mt MeasurementType
+		if err := rows.Scan(&mt.ID, &mt.Name, &mt.Unit, &mt.IsDefault); err != nil {
+			return nil, err
+		}
+		types = append(types, mt)
+	}
+	return types, nil
+}
+
+// getMeasurements returns measurement history ordered by date. A typeID of 0
+// returns the history for every measurement type.
+func getMeasurements(typeID int) ([]Measurement, error) {
+	query := `
+		SELECT m.id, m.type_id, t.name, t.unit, m.date, m.value
+		FROM measurements m
+		JOIN measurement_types t ON m.type_id = t.id`
+	var args []interface{}
+	if typeID != 0 {
+		query += " WHERE m.type_id = ?"
+		args = append(args, typeID)
+	}
+	query += " ORDER BY m.date, m.id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	measurements := []Measurement{}
+	for rows.Next() {
+		var m Measurement
+		if err := rows.Scan(&m.ID, &m.TypeID, &m.Type, &m.Unit, &m.Date, &m.Value); err != nil {
+			return nil, err
+		}
+		measurements = append(measurements, m)
+	}
+	return measurements, nil
+}
+
+func measurementsPage(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := template.ParseFiles("templates/measurements.html")
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		log.Printf("Error parsing measurements template: %v", err)
+		return
+	}
+
+	err = tmpl.Execute(w, struct{ Today string }{Today: time.Now().Format("2006-01-02")})
+	if err != nil {
+		http.Error(w, "Template execution error", http.StatusInternalServerError)
+		log.Printf("Error executing measurements template: %v", err)
+	}
+}
+
+func handleMeasurementTypesAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "GET":
+		types, err := getMeasurementTypes()
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(types)
+
+	case "POST":
+		var mt MeasurementType
+		if err := json.NewDecoder(r.Body).Decode(&mt); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if mt.Name == "" {
+			http.Error(w, "Name is required", http.StatusBadRequest)
+			return
+		}
+		if mt.Unit == "" {
+			mt.Unit = "cm"
+		}
+		result, err := db.Exec("INSERT INTO measurement_types (name, unit, is_default) VALUES (?, ?, 0)", mt.Name, mt.Unit)
+		if err != nil {
+			http.Error(w, "Measurement type already exists or database error", http.StatusConflict)
+			return
+		}
+		id, _ := result.LastInsertId()
+		mt.ID = int(id)
+		mt.IsDefault = false
+		json.NewEncoder(w).Encode(mt)
+
+	case "DELETE":
+		idStr := r.URL.Query().Get("id")
+		if idStr == "" {
+			http.Error(w, "ID is required", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		// Protect default measurement types
+		var isDefault bool
+		db.QueryRow("SELECT is_default FROM measurement_types WHERE id = ?", id).Scan(&isDefault)
+		if isDefault {
+			http.Error(w, "Cannot delete default measurement types", http.StatusForbidden)
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+
+		// Delete history first (foreign key constraint)
+		if _, err := tx.Exec("DELETE FROM measurements WHERE type_id = ?", id); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if _, err := tx.Exec("DELETE FROM measurement_types WHERE id = ?", id); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"success": true}`)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleMeasurementsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "GET":
+		typeID := 0
+		if typeStr := r.URL.Query().Get("type_id"); typeStr != "" {
+			var err error
+			typeID, err = strconv.Atoi(typeStr)
+			if err != nil {
+				http.Error(w, "Invalid type ID", http.StatusBadRequest)
+				return
+			}
+		}
+		measurements, err := getMeasurements(typeID)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error querying measurements: %v", err)
+			return
+		}
+		json.NewEncoder(w).Encode(measurements)
+
+	case "POST":
+		var m Measurement
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if m.TypeID == 0 || m.Date == "" {
+			http.Error(w, "Type ID and date are required", http.StatusBadRequest)
+			return
+		}
+		if m.Value <= 0 {
+			http.Error(w, "Value must be positive", http.StatusBadRequest)
+			return
+		}
+		err := db.QueryRow("SELECT name, unit FROM measurement_types WHERE id = ?", m.TypeID).Scan(&m.Type, &m.Unit)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Measurement type not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		result, err := db.Exec("INSERT INTO measurements (type_id, date, value) VALUES (?, ?, ?)", m.TypeID, m.Date, m.Value)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error saving measurement: %v", err)
+			return
+		}
+		id, _ := result.LastInsertId()
+		m.ID = int(id)
+		json.NewEncoder(w).Encode(m)
+
+	case "DELETE":
+		idStr := r.URL.Query().Get("id")
+		if idStr == "" {
+			http.Error(w, "ID is required", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		result, err := db.Exec("DELETE FROM measurements WHERE id = ?", id)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			http.Error(w, "Measurement not found", http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"success": true}`)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+type ExportData struct {
+	ExportedAt        string             `json:"exported_at"`
+	Workouts          []Workout          `json:"workouts"`
+	ExerciseLibrary   []ExerciseDB       `json:"exercise_library"`
+	GZCLPDayExercises []GZCLPDayExercise `json:"gzclp_day_exercises"`
+	MeasurementTypes  []MeasurementType  `json:"measurement_types"`
+	Measurements      []Measurement      `json:"measurements"`
+	PersonalRecords   []PersonalRecord   `json:"personal_records"`
+	WorkoutTemplates  []WorkoutTemplate  `json:"workout_templates"`
+	GZCLPEvents       []GZCLPEvent       `json:"gzclp_events"`
+	SkippedSessions   []SkippedSession   `json:"skipped_sessions"`
+	TrainingMaxes     []TrainingMax      `json:"training_maxes"`
+	GZCLPResets       []GZCLPReset       `json:"gzclp_resets"`
+}
+
+func buildExportData() (ExportData, error) {
+	export := ExportData{ExportedAt: time.Now().Format(time.RFC3339)}
+
+	var err error
+	if export.Workouts, err = getWorkoutsFromDB(); err != nil {
+		return export, err
+	}
+	if export.ExerciseLibrary, err = getAllExercises(); err != nil {
+		return export, err
+	}
+	if export.GZCLPDayExercises, err = getGZCLPAllDayExercises(); err != nil {
+		return export, err
+	}
+	if export.MeasurementTypes, err = getMeasurementTypes(); err != nil {
+		return export, err
+	}
+	if export.Measurements, err = getMeasurements(0); err != nil {
+		return export, err
+	}
+	if export.PersonalRecords, err = getPersonalRecords("", 0); err != nil {
+		return export, err
+	}
+	if export.WorkoutTemplates, err = getWorkoutTemplates(); err != nil {
+		return export, err
+	}
+	if export.GZCLPEvents, err = getGZCLPEvents(db); err != nil {
+		return export, err
+	}
+	if export.SkippedSessions, err = getSkippedSessions("", ""); err != nil {
+		return export, err
+	}
+	if export.TrainingMaxes, err = getTrainingMaxHistory(""); err != nil {
+		return export, err
+	}
+	if export.GZCLPResets, err = getGZCLPResets(""); err != nil {
+		return export, err
+	}
+
+	if export.Workouts == nil {
+		export.Workouts = []Workout{}
+	}
+	if export.ExerciseLibrary == nil {
+		export.ExerciseLibrary = []ExerciseDB{}
+	}
+	if export.GZCLPDayExercises == nil {
+		export.GZCLPDayExercises = []GZCLPDayExercise{}
+	}
+	return export, nil
+}
+
+func exportData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	export, err := buildExportData()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error building export: %v", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="trucker-backup-%s.json"`, time.Now().Format("2006-01-02")))
+	if err := json.NewEncoder(w).Encode(export); err != nil {
+		log.Printf("Error encoding export: %v", err)
+	}
+}
+
+type RepMax struct {
+	Reps   int     `json:"reps"`
+	Weight float64 `json:"weight"`
+	Date   string  `json:"date"`
+}
+
+type RecordsResponse struct {
+	Exercises []string         `json:"exercises"`
+	Records   []PersonalRecord `json:"records"`
+	RepMaxes  []RepMax         `json:"rep_maxes"`
+}
+
+// getRepMaxes returns the current best weight lifted for at least N reps,
+// for N from 1 to maxRepMaxReps, along with the date it was first achieved.
+func getRepMaxes(exerciseName string) ([]RepMax, error) {
+	rows, err := db.Query(`
+		SELECT w.date, s.weight, s.reps
+		FROM sets s
+		JOIN exercises e ON s.exercise_id = e.id
+		JOIN workouts w ON e.workout_id = w.id
+		WHERE e.name = ? AND s.weight > 0
+		ORDER BY w.date, s.id
+	`, exerciseName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	best := make([]RepMax, maxRepMaxReps+1)
+	for rows.Next() {
+		var date string
+		var weight float64
+		var reps int
+		if err := rows.Scan(&date, &weight, &reps); err != nil {
+			return nil, err
+		}
+		for n := 1; n <= reps && n <= maxRepMaxReps; n++ {
+			if weight > best[n].Weight {
+				best[n] = RepMax{Reps: n, Weight: weight, Date: date}
+			}
+		}
+	}
+
+	repMaxes := []RepMax{}
+	for n := 1; n <= maxRepMaxReps; n++ {
+		if best[n].Weight > 0 {
+			repMaxes = append(repMaxes, best[n])
+		}
+	}
+	return repMaxes, nil
+}
+
+func recordsPage(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := template.ParseFiles("templates/records.html")
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		log.Printf("Error parsing records template: %v", err)
+		return
+	}
+
+	err = tmpl.Execute(w, struct{ Exercise string }{Exercise: r.URL.Query().Get("exercise")})
+	if err != nil {
+		http.Error(w, "Template execution error", http.StatusInternalServerError)
+		log.Printf("Error executing records template: %v", err)
+	}
+}
+
+func handleRecordsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := RecordsResponse{
+		Exercises: []string{},
+		Records:   []PersonalRecord{},
+		RepMaxes:  []RepMax{},
+	}
+
+	exerciseName := r.URL.Query().Get("exercise")
+	if exerciseName == "" {
+		// List every logged exercise, plus the most recent PRs overall
+		rows, err := db.Query(`
+			SELECT DISTINCT e.name
+			FROM exercises e
+			JOIN workouts w ON e.workout_id = w.id
+			ORDER BY e.name
+		`)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error querying record exercises: %v", err)
+			return
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				continue
+			}
+			response.Exercises = append(response.Exercises, name)
+		}
+	} else {
+		repMaxes, err := getRepMaxes(exerciseName)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error querying rep maxes: %v", err)
+			return
+		}
+		response.RepMaxes = repMaxes
+	}
+
+	records, err := getPersonalRecords(exerciseName, 0)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error querying personal records: %v", err)
+		return
+	}
+	response.Records = records
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// TrainingMax is one entry in an exercise's training max history. The
+// latest entry by date is the current training max.
+type TrainingMax struct {
+	ID           int     `json:"id"`
+	ExerciseName string  `json:"exercise_name"`
+	Value        float64 `json:"value"`
+	Date         string  `json:"date"`
+	Note         string  `json:"note"`
+}
+
+type TrainingMaxResponse struct {
+	ExerciseName string        `json:"exercise_name"`
+	Current      *TrainingMax  `json:"current"`
+	History      []TrainingMax `json:"history"`
+	Percent      float64       `json:"percent,omitempty"`
+	Weight       float64       `json:"weight,omitempty"`
+}
+
+// maxTrainingMaxPercent bounds percentage prescriptions, leaving room for
+// overloads above the training max.
+const maxTrainingMaxPercent = 150
+
+// percentOfTrainingMax is the rounded load for percent of a training max.
+func percentOfTrainingMax(trainingMax, percent, increment float64) float64 {
+	return roundWeightFor(trainingMax*percent/100, increment)
+}
+
+// getTrainingMaxHistory returns training max entries ordered by date. An
+// empty exerciseName returns the history of every exercise.
+func getTrainingMaxHistory(exerciseName string) ([]TrainingMax, error) {
+	query := "SELECT id, exercise_name, value, date, note FROM training_maxes"
+	var args []interface{}
+	if exerciseName != "" {
+		query += " WHERE exercise_name = ?"
+		args = append(args, exerciseName)
+	}
+	query += " ORDER BY exercise_name, date, id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []TrainingMax{}
+	for rows.Next() {
+		var tm TrainingMax
+		if err := rows.Scan(&tm.ID, &tm.ExerciseName, &tm.Value, &tm.Date, &tm.Note); err != nil {
+			return nil, err
+		}
+		history = append(history, tm)
+	}
+	return history, rows.Err()
+}
+
+// getCurrentTrainingMaxes maps each exercise with a training max to its
+// latest value.
+func getCurrentTrainingMaxes() (map[string]float64, error) {
+	history, err := getTrainingMaxHistory("")
+	if err != nil {
+		return nil, err
+	}
+	current := map[string]float64{}
+	for _, tm := range history {
+		current[tm.ExerciseName] = tm.Value
+	}
+	return current, nil
+}
+
+func handleTrainingMaxAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "GET":
+		exerciseName := r.URL.Query().Get("exercise")
+		if exerciseName == "" {
+			// Without an exercise, list the current training max of each
+			history, err := getTrainingMaxHistory("")
+			if err != nil {
+				http.Error(w, "Database error", http.StatusInternalServerError)
+				log.Printf("Error querying training maxes: %v", err)
+				return
+			}
+			current := []TrainingMax{}
+			for i, tm := range history {
+				if i+1 == len(history) || history[i+1].ExerciseName != tm.ExerciseName {
+					current = append(current, tm)
+				}
+			}
+			json.NewEncoder(w).Encode(current)
+			return
+		}
+
+		history, err := getTrainingMaxHistory(exerciseName)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error querying training maxes: %v", err)
+			return
+		}
+		response := TrainingMaxResponse{ExerciseName: exerciseName, History: history}
+		if len(history) > 0 {
+			response.Current = &history[len(history)-1]
+		}
+		if percentStr := r.URL.Query().Get("percent"); percentStr != "" {
+			percent, err := strconv.ParseFloat(percentStr, 64)
+			if err != nil || percent <= 0 || percent > maxTrainingMaxPercent {
+				http.Error(w, fmt.Sprintf("Percent must be between 0 and %d", maxTrainingMaxPercent), http.StatusBadRequest)
+				return
+			}
+			response.Percent = percent
+			if response.Current != nil {
+				increments, err := getExerciseIncrements()
+				if err != nil {
+					http.Error(w, "Database error", http.StatusInternalServerError)
+					return
+				}
+				response.Weight = percentOfTrainingMax(response.Current.Value, percent, incrementFor(increments, exerciseName))
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+
+	case "POST":
+		var tm TrainingMax
+		if err := json.NewDecoder(r.Body).Decode(&tm); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		tm.ExerciseName = strings.TrimSpace(tm.ExerciseName)
+		tm.Note = strings.TrimSpace(tm.Note)
+		if tm.ExerciseName == "" {
+			http.Error(w, "Exercise name is required", http.StatusBadRequest)
+			return
+		}
+		if tm.Value <= 0 {
+			http.Error(w, "Value must be positive", http.StatusBadRequest)
+			return
+		}
+		if tm.Date == "" {
+			tm.Date = time.Now().Format("2006-01-02")
+		}
+		if _, err := time.Parse("2006-01-02", tm.Date); err != nil {
+			http.Error(w, "Invalid date", http.StatusBadRequest)
+			return
+		}
+		result, err := db.Exec("INSERT INTO training_maxes (exercise_name, value, date, note) VALUES (?, ?, ?, ?)",
+			tm.ExerciseName, tm.Value, tm.Date, tm.Note)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error saving training max: %v", err)
+			return
+		}
+		id, _ := result.LastInsertId()
+		tm.ID = int(id)
+		json.NewEncoder(w).Encode(tm)
+
+	case "DELETE":
+		idStr := r.URL.Query().Get("id")
+		if idStr == "" {
+			http.Error(w, "ID is required", http.StatusBadRequest)
+			return
+		}
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		result, err := db.Exec("DELETE FROM training_maxes WHERE id = ?", id)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			http.Error(w, "Training max not found", http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"success": true}`)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// TemplateExercise is one line of a template. A Percent above zero
+// prescribes the load as a share of the exercise's training max, with
+// Weight as the fallback while no training max is set.
+type TemplateExercise struct {
+	ExerciseName string  `json:"exercise_name"`
+	Sets         int     `json:"sets"`
+	Reps         int     `json:"reps"`
+	Weight       float64 `json:"weight"`
+	Percent      float64 `json:"percent,omitempty"`
+}
+
+type WorkoutTemplate struct {
+	ID        int                `json:"id"`
+	Name      string             `json:"name"`
+	Exercises []TemplateExercise `json:"exercises"`
+}
+
+// maxTemplateSets bounds the target set count of a template exercise.
+const maxTemplateSets = 20
+
+// toExercises expands a template into workout exercises with one set per
+// target set, ready to prefill the workout form. Percentage lines are
+// computed from trainingMaxes and rounded for each exercise's increment.
+func (t WorkoutTemplate) toExercises(trainingMaxes, increments map[string]float64) []Exercise {
+	exercises := []Exercise{}
+	for _, te := range t.Exercises {
+		exercise := Exercise{Name: te.ExerciseName, Sets: []Set{}}
+		weight := te.Weight
+		if tm, ok := trainingMaxes[te.ExerciseName]; ok && te.Percent > 0 {
+			weight = percentOfTrainingMax(tm, te.Percent, incrementFor(increments, te.ExerciseName))
+		}
+		for i := 0; i < te.Sets; i++ {
+			exercise.Sets = append(exercise.Sets, Set{Reps: te.Reps, Weight: weight})
+		}
+		exercises = append(exercises, exercise)
+	}
+	return exercises
+}
+
+func validateWorkoutTemplate(t WorkoutTemplate) string {
+	if strings.TrimSpace(t.Name) == "" {
+		return "Template name is required"
+	}
+	if len(t.Exercises) == 0 {
+		return "Template needs at least one exercise"
+	}
+	for _, te := range t.Exercises {
+		if te.ExerciseName == "" {
+			return "Exercise name is required"
+		}
+		if te.Sets < 1 || te.Sets > maxTemplateSets {
+			return fmt.Sprintf("Sets must be between 1 and %d", maxTemplateSets)
+		}
+		if te.Reps < 1 {
+			return "Reps must be at least 1"
+		}
+		if te.Weight < 0 {
+			return "Weight cannot be negative"
+		}
+		if te.Percent < 0 || te.Percent > maxTrainingMaxPercent {
+			return fmt.Sprintf("Percent must be between 0 and %d", maxTrainingMaxPercent)
+		}
+	}
+	return ""
+}
+
+func getWorkoutTemplates() ([]WorkoutTemplate, error) {
+	rows, err := db.Query(`
+		SELECT t.id, t.name, te.exercise_name, te.sets, te.reps, te.weight, te.percent
+		FROM workout_templates t
+		LEFT JOIN template_exercises te ON te.template_id = t.id
+		ORDER BY t.name, t.id, te.position
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []WorkoutTemplate{}
+	for rows.Next() {
+		var id int
+		var name string
+		var exerciseName sql.NullString
+		var sets, reps sql.NullInt64
+		var weight, percent sql.NullFloat64
+		if err := rows.Scan(&id, &name, &exerciseName, &sets, &reps, &weight, &percent); err != nil {
+			return nil, err
+		}
+		if len(templates) == 0 || templates[len(templates)-1].ID != id {
+			templates = append(templates, WorkoutTemplate{ID: id, Name: name, Exercises: []TemplateExercise{}})
+		}
+		if exerciseName.Valid {
+			t := &templates[len(templates)-1]
+			t.Exercises = append(t.Exercises, TemplateExercise{
+				ExerciseName: exerciseName.String,
+				Sets:         int(sets.Int64),
+				Reps:         int(reps.Int64),
+				Weight:       weight.Float64,
+				Percent:      percent.Float64,
+			})
+		}
+	}
+	return templates, nil
+}
+
+func getWorkoutTemplate(id int) (WorkoutTemplate, error) {
+	templates, err := getWorkoutTemplates()
+	if err != nil {
+		return WorkoutTemplate{}, err
+	}
+	for _, t := range templates {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return WorkoutTemplate{}, sql.ErrNoRows
+}
+
+// saveTemplateExercises replaces the exercise list of a template.
+func saveTemplateExercises(tx *sql.Tx, templateID int, exercises []TemplateExercise) error {
+	if _, err := tx.Exec("DELETE FROM template_exercises WHERE template_id = ?", templateID); err != nil {
+		return err
+	}
+	for i, te := range exercises {
+		_, err := tx.Exec(`
+			INSERT INTO template_exercises (template_id, position, exercise_name, sets, reps, weight, percent)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, templateID, i, te.ExerciseName, te.Sets, te.Reps, te.Weight, te.Percent)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func templatesPage(w http.ResponseWriter, r *http.Request) {
+	exercises, err := getAllExercises()
+	if err != nil {
+		log.Printf("Error loading exercises: %v", err)
+		exercises = []ExerciseDB{}
+	}
+
+	tmpl, err := template.ParseFiles("templates/templates.html")
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		log.Printf("Error parsing templates template: %v", err)
+		return
+	}
+
+	err = tmpl.Execute(w, struct{ Exercises []ExerciseDB }{Exercises: exercises})
+	if err != nil {
+		http.Error(w, "Template execution error", http.StatusInternalServerError)
+		log.Printf("Error executing templates template: %v", err)
+	}
+}
+
+func handleTemplatesAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "GET":
+		if idStr := r.URL.Query().Get("id"); idStr != "" {
+			id, err := strconv.Atoi(idStr)
+			if err != nil {
+				http.Error(w, "Invalid ID", http.StatusBadRequest)
+				return
+			}
+			t, err := getWorkoutTemplate(id)
+			if err == sql.ErrNoRows {
+				http.Error(w, "Template not found", http.StatusNotFound)
+				return
+			} else if err != nil {
+				http.Error(w, "Database error", http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(t)
+			return
+		}
+		templates, err := getWorkoutTemplates()
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error querying workout templates: %v", err)
+			return
+		}
+		json.NewEncoder(w).Encode(templates)
+
+	case "POST", "PUT":
+		var t WorkoutTemplate
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		t.Name = strings.TrimSpace(t.Name)
+		if msg := validateWorkoutTemplate(t); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		if r.Method == "PUT" && t.ID == 0 {
+			http.Error(w, "ID is required", http.StatusBadRequest)
+			return
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+
+		var result sql.Result
+		if r.Method == "POST" {
+			result, err = tx.Exec("INSERT INTO workout_templates (name) VALUES (?)", t.Name)
+		} else {
+			result, err = tx.Exec("UPDATE workout_templates SET name = ? WHERE id = ?", t.Name, t.ID)
+		}
+		if err != nil {
+			if strings.Contains(err.Error(), "UNIQUE") {
+				http.Error(w, "A template with this name already exists", http.StatusConflict)
+				return
+			}
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error saving workout template: %v", err)
+			return
+		}
+		if r.Method == "POST" {
+			id, _ := result.LastInsertId()
+			t.ID = int(id)
+		} else if n, _ := result.RowsAffected(); n == 0 {
+			http.Error(w, "Template not found", http.StatusNotFound)
+			return
+		}
+
+		if err := saveTemplateExercises(tx, t.ID, t.Exercises); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error saving template exercises: %v", err)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(t)
+
+	case "DELETE":
+		id, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
+			return
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+
+		result, err := tx.Exec("DELETE FROM workout_templates WHERE id = ?", id)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			http.Error(w, "Template not found", http.StatusNotFound)
+			return
+		}
+		if _, err := tx.Exec("DELETE FROM template_exercises WHERE template_id = ?", id); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"success": true}`)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Draft forms: one in-progress draft is kept per workout form.
+var draftForms = []string{"workout", "gzclp"}
+
+type Draft struct {
+	Form      string  `json:"form"`
+	Workout   Workout `json:"workout"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+func getDraft(form string) (Draft, error) {
+	draft := Draft{Form: form}
+	var data string
+	err := db.QueryRow("SELECT data, updated_at FROM workout_drafts WHERE form = ?", form).Scan(&data, &draft.UpdatedAt)
+	if err != nil {
+		return draft, err
+	}
+	err = json.Unmarshal([]byte(data), &draft.Workout)
+	return draft, err
+}
+
+func saveDraft(form string, workout Workout) (Draft, error) {
+	draft := Draft{Form: form, Workout: workout, UpdatedAt: time.Now().Format(time.RFC3339)}
+	data, err := json.Marshal(workout)
+	if err != nil {
+		return draft, err
+	}
+	_, err = db.Exec("INSERT OR REPLACE INTO workout_drafts (form, data, updated_at) VALUES (?, ?, ?)", form, string(data), draft.UpdatedAt)
+	return draft, err
+}
+
+func deleteDraft(form string) error {
+	_, err := db.Exec("DELETE FROM workout_drafts WHERE form = ?", form)
+	return err
+}
+
+// completedWorkout strips a draft down to what the form would submit: like
+// createWorkout it skips sets whose weight or reps were left blank, and
+// exercises without any sets left are dropped.
+func completedWorkout(draft Workout) Workout {
+	workout := draft
+	workout.Exercises = []Exercise{}
+	for _, exercise := range draft.Exercises {
+		if exercise.Name == "" {
+			continue
+		}
+		done := Exercise{Name: exercise.Name, Slot: exercise.Slot, Tier: exercise.Tier, Stage: exercise.Stage, Sets: []Set{}}
+		for _, set := range exercise.Sets {
+			if !set.Empty {
+				done.Sets = append(done.Sets, set)
+			}
+		}
+		if len(done.Sets) > 0 {
+			workout.Exercises = append(workout.Exercises, done)
+		}
+	}
+	if workout.WorkoutType == "" {
+		workout.WorkoutType = "custom"
+	}
+	return workout
+}
+
+func handleDraftsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	form := r.URL.Query().Get("form")
+	if !contains(draftForms, form) {
+		http.Error(w, "form must be workout or gzclp", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		draft, err := getDraft(form)
+		if err == sql.ErrNoRows {
+			http.Error(w, "No draft", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error loading draft: %v", err)
+			return
+		}
+		json.NewEncoder(w).Encode(draft)
+
+	case "PUT":
+		var workout Workout
+		if err := json.NewDecoder(r.Body).Decode(&workout); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		draft, err := saveDraft(form, workout)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			log.Printf("Error saving draft: %v", err)
+			return
+		}
+		json.NewEncoder(w).Encode(draft)
+
+	case "DELETE":
+		if err := deleteDraft(form); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"success": true}`)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func finishDraft(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	form := r.URL.Query().Get("form")
+	draft, err := getDraft(form)
+	if err == sql.ErrNoRows {
+		http.Error(w, "No draft", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error loading draft: %v", err)
+		return
+	}
+
+	workout := completedWorkout(draft.Workout)
+	if workout.Date == "" {
+		http.Error(w, "Draft has no date", http.StatusBadRequest)
+		return
+	}
+	if len(workout.Exercises) == 0 {
+		http.Error(w, "Draft has no completed sets", http.StatusBadRequest)
+		return
+	}
+	for _, exercise := range workout.Exercises {
+		if msg := validateExerciseTier(exercise); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := recordWorkout(workout, false)
+	if conflict, ok := err.(*GZCLPDayConflict); ok {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(conflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to save workout", http.StatusInternalServerError)
+		log.Printf("Error saving workout from draft: %v", err)
+		return
+	}
+	if err := deleteDraft(form); err != nil {
+		log.Printf("Error deleting draft: %v", err)
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
+
+// serviceWorker serves the service worker from the site root so its scope
+// covers every page, not just /static/.
+func serviceWorker(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeFile(w, r, "static/sw.js")
+}
+
+// SyncItem is a workout queued by the offline client. The client generates
+// ClientID when the workout is queued, so replaying the same item is safe.
+type SyncItem struct {
+	ClientID  string  `json:"client_id"`
+	DraftForm string  `json:"draft_form,omitempty"`
+	Force     bool    `json:"force"`
+	Workout   Workout `json:"workout"`
+}
+
+type SyncResult struct {
+	ClientID   string           `json:"client_id"`
+	Status     string           `json:"status"` // created, duplicate, conflict or invalid
+	WorkoutID  int              `json:"workout_id,omitempty"`
+	CurrentDay int              `json:"current_day,omitempty"`
+	Message    string           `json:"message,omitempty"`
+	Records    []PersonalRecord `json:"records,omitempty"`
+}
+
+type SyncResponse struct {
+	Results []SyncResult `json:"results"`
+}
+
+func getWorkoutIDByClientID(clientID string) (int, error) {
+	var id int
+	err := db.QueryRow("SELECT id FROM workouts WHERE client_id = ?", clientID).Scan(&id)
+	return id, err
+}
+
+// savedWorkoutResult rebuilds the result of an earlier save so a repeated
+// submission gets the same answer as the first one.
+func savedWorkoutResult(workoutID int) (SaveWorkoutResult, error) {
+	records, err := getPersonalRecords("", workoutID)
+	return SaveWorkoutResult{WorkoutID: workoutID, Records: records, Duplicate: true}, err
+}
+
+// newFormToken returns a random token that identifies one rendering of a
+// workout form, used as the idempotency key of its submission.
+func newFormToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf("Error generating form token: %v", err)
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// syncWorkout saves one queued workout. A client ID that was already synced
+// is reported as a duplicate instead of being saved twice. A GZCLP workout
+// for a day the rotation has since moved past is a conflict; when the client
+// forces it, it is saved as an override without touching the rotation.
+func syncWorkout(item SyncItem) (SyncResult, error) {
+	result := SyncResult{ClientID: item.ClientID}
+	if item.ClientID == "" {
+		result.Status = "invalid"
+		result.Message = "client_id is required"
+		return result, nil
+	}
+
+	if id, err := getWorkoutIDByClientID(item.ClientID); err == nil {
+		result.Status = "duplicate"
+		result.WorkoutID = id
+		return result, nil
+	} else if err != sql.ErrNoRows {
+		return result, err
+	}
+
+	workout := completedWorkout(item.Workout)
+	workout.ClientID = item.ClientID
+	if _, err := time.Parse("2006-01-02", workout.Date); err != nil {
+		result.Status = "invalid"
+		result.Message = "Invalid date"
+		return result, nil
+	}
+	if len(workout.Exercises) == 0 {
+		result.Status = "invalid"
+		result.Message = "Workout has no completed sets"
+		return result, nil
+	}
+	for _, exercise := range workout.Exercises {
+		if msg := validateExerciseTier(exercise); msg != "" {
+			result.Status = "invalid"
+			result.Message = msg
+			return result, nil
+		}
+	}
+
+	saved, err := recordWorkout(workout, false)
+	if conflict, ok := err.(*GZCLPDayConflict); ok {
+		if !item.Force {
+			result.Status = "conflict"
+			result.CurrentDay = conflict.CurrentDay
+			result.Message = fmt.Sprintf("Logged as GZCLP day %d, but the program is now on day %d", conflict.SubmittedDay, conflict.CurrentDay)
+			return result, nil
+		}
+		saved, err = recordWorkout(workout, true)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if item.DraftForm != "" {
+		if err := deleteDraft(item.DraftForm); err != nil {
+			log.Printf("Error deleting draft: %v", err)
+		}
+	}
+
+	result.Status = "created"
+	if saved.Duplicate {
+		result.Status = "duplicate"
+	}
+	result.WorkoutID = saved.WorkoutID
+	result.Records = saved.Records
+	return result, nil
+}
+
+// syncWorkouts replays the offline queue in the order it was recorded and
+// reports the outcome of every item, so the client knows what to drop. A
+// database error stops the replay but still reports the items already saved;
+// the rest stay queued on the client and are retried in order.
+func syncWorkouts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Workouts []SyncItem `json:"workouts"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	results := []SyncResult{}
+	for _, item := range request.Workouts {
+		result, err := syncWorkout(item)
+		if err != nil {
+			log.Printf("Error syncing workout %s: %v", item.ClientID, err)
+			break
+		}
+		results = append(results, result)
+	}
+
+	json.NewEncoder(w).Encode(SyncResponse{Results: results})
+}
+
+// GZCLPEvent is one change to the GZCLP program: a completed or deleted
+// workout, a skipped day, a manual day change or a config change. The
+// events are the history of the program and the source of truth for its
+// state; gzclp_settings caches the state derived from them so readers don't
+// replay the history. Changes go through applyGZCLPEvent, which appends the
+// event and rewrites the cache, and checkGZCLPState repairs the cache at
+// startup.
+type GZCLPEvent struct {
+	ID        int    `json:"id"`
+	Type      string `json:"type"` // baseline, workout, workout_deleted, skip, day_change, config, rotation or reset
+	FromDay   int    `json:"from_day"`
+	ToDay     int    `json:"to_day"`
+	WorkoutID int    `json:"workout_id,omitempty"`
+	Detail    string `json:"detail"`
+	Data      string `json:"-"` // what the event leaves queued, or what undo needs to restore, e.g. the previous config
+	Undone    bool   `json:"undone"`
+	CreatedAt string `json:"created_at"`
+}
+
+type GZCLPState struct {
+	CurrentDay  int   `json:"current_day"`
+	SkippedDays int   `json:"skipped_days"`
+	Upcoming    []int `json:"upcoming"` // days queued ahead of the normal rotation by a swap
+	Days        int   `json:"days"`     // length of the rotation
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func loadGZCLPState(q queryRower) (GZCLPState, error) {
+	var state GZCLPState
+	var upcoming string
+	err := q.QueryRow("SELECT current_day, skipped_days, upcoming_days, rotation_days FROM gzclp_settings WHERE id = 1").
+		Scan(&state.CurrentDay, &state.SkippedDays, &upcoming, &state.Days)
+	if err != nil {
+		return state, err
+	}
+	json.Unmarshal([]byte(upcoming), &state.Upcoming)
+	return state, nil
+}
+
+func encodeDays(days []int) string {
+	if len(days) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(days)
+	return string(data)
+}
+
+func (s GZCLPState) rotationDays() int {
+	if s.Days < 1 {
+		return defaultGZCLPDays
+	}
+	return s.Days
+}
+
+// advance returns the day after finishing (or skipping) day, and what is
+// left queued: queued days come first, then the normal rotation.
+func (s GZCLPState) advance(day int) (int, []int) {
+	if len(s.Upcoming) > 0 {
+		return s.Upcoming[0], s.Upcoming[1:]
+	}
+	return (day % s.rotationDays()) + 1, nil
+}
+
+type GZCLPHistory struct {
+	State  GZCLPState   `json:"state"`
+	Events []GZCLPEvent `json:"events"`
+}
+
+var (
+	errNothingToUndo     = errors.New("nothing to undo")
+	errUndoWorkout       = errors.New("the last event is a logged workout; delete the workout to undo it")
+	errUndoDeleted       = errors.New("the last event is a deleted workout; log the workout again to undo it")
+	errGZCLPStateChanged = errors.New("the GZCLP program changed while saving")
+)
+
+func logGZCLPEvent(tx *sql.Tx, e GZCLPEvent) (int, error) {
+	result, err := tx.Exec(`INSERT INTO gzclp_events (event_type, from_day, to_day, workout_id, detail, data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		e.Type, e.FromDay, e.ToDay, e.WorkoutID, e.Detail, e.Data, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// applyGZCLPEvent appends an event to the history and rewrites the cached
+// state from it. It returns errGZCLPStateChanged when the cache is no longer
+// on the event's FromDay, so a concurrent change is not overwritten.
+func applyGZCLPEvent(tx *sql.Tx, e GZCLPEvent) (int, GZCLPState, error) {
+	id, err := logGZCLPEvent(tx, e)
+	if err != nil {
+		return 0, GZCLPState{}, err
+	}
+	state, err := saveGZCLPState(tx, e.FromDay)
+	return id, state, err
+}
+
+// saveGZCLPState replays the history and writes the state it ends on to
+// gzclp_settings. This is the only place the cached state is written. A
+// fromDay above zero only writes while the cache is still on that day.
+func saveGZCLPState(tx *sql.Tx, fromDay int) (GZCLPState, error) {
+	events, err := getGZCLPEvents(tx)
+	if err != nil {
+		return GZCLPState{}, err
+	}
+	state := deriveGZCLPState(events)
+	state.Days = getGZCLPRotationDays(tx)
+
+	query := "UPDATE gzclp_settings SET current_day = ?, skipped_days = ?, upcoming_days = ? WHERE id = 1"
+	args := []interface{}{state.CurrentDay, state.SkippedDays, encodeDays(state.Upcoming)}
+	if fromDay > 0 {
+		query += " AND current_day = ?"
+		args = append(args, fromDay)
+	}
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return state, err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return state, err
+	} else if n == 0 {
+		return state, errGZCLPStateChanged
+	}
+	return state, nil
+}
+
+// ensureGZCLPBaseline records the program state of a database that predates
+// the event history, so the history starts from where the user already was.
+func ensureGZCLPBaseline() {
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM gzclp_events").Scan(&count)
+	if count > 0 {
+		return
+	}
+	state, err := loadGZCLPState(db)
+	if err != nil {
+		return
+	}
+	data, _ := json.Marshal(state)
+	db.Exec("INSERT INTO gzclp_events (event_type, to_day, detail, data, created_at) VALUES ('baseline', ?, 'Program history started', ?, ?)",
+		state.CurrentDay, string(data), time.Now().Format(time.RFC3339))
+}
+
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func getGZCLPEvents(q queryer) ([]GZCLPEvent, error) {
+	rows, err := q.Query(`SELECT id, event_type, from_day, to_day, workout_id, detail, data, undone, created_at
+		FROM gzclp_events ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []GZCLPEvent{}
+	for rows.Next() {
+		var e GZCLPEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.FromDay, &e.ToDay, &e.WorkoutID, &e.Detail, &e.Data, &e.Undone, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// deriveGZCLPState replays the events that have not been undone. Every
+// event except a config change leaves the rotation on its ToDay. The
+// rotation length is not part of the replay; callers take it from settings.
+func deriveGZCLPState(events []GZCLPEvent) GZCLPState {
+	state := GZCLPState{CurrentDay: 1}
+	for _, e := range events {
+		if e.Undone {
+			continue
+		}
+		switch e.Type {
+		case "baseline":
+			json.Unmarshal([]byte(e.Data), &state)
+		case "config", "reset":
+		case "rotation":
+			state.CurrentDay = e.ToDay
+			state.Upcoming = nil
+		case "day_change", "workout_deleted":
+			state.CurrentDay = e.ToDay
+			state.Upcoming = nil
+			json.Unmarshal([]byte(e.Data), &state.Upcoming)
+		case "skip", "workout":
+			if e.Type == "skip" {
+				state.SkippedDays++
+			}
+			state.CurrentDay = e.ToDay
+			if e.Data != "" {
+				// Workouts keep the days they leave queued, since an
+				// override drops a swapped order
+				state.Upcoming = nil
+				json.Unmarshal([]byte(e.Data), &state.Upcoming)
+			} else if len(state.Upcoming) > 0 && state.Upcoming[0] == e.ToDay {
+				state.Upcoming = state.Upcoming[1:]
+			} else {
+				state.Upcoming = nil
+			}
+		default:
+			state.CurrentDay = e.ToDay
+		}
+	}
+	return state
+}
+
+// undoLastGZCLPEvent marks the latest event as undone and rebuilds the
+// program state without it. Config changes restore the previous exercise
+// assignments. Workouts are not undone here, since that would leave the
+// workout itself behind; deleting it rewinds the rotation instead.
+func undoLastGZCLPEvent() (GZCLPEvent, GZCLPState, error) {
+	var undone GZCLPEvent
+	var state GZCLPState
+
+	tx, err := db.Begin()
+	if err != nil {
+		return undone, state, err
+	}
+	defer tx.Rollback()
+
+	events, err := getGZCLPEvents(tx)
+	if err != nil {
+		return undone, state, err
+	}
+	found := false
+	for i := len(events) - 1; i >= 0; i-- {
+		if !events[i].Undone && events[i].Type != "baseline" {
+			events[i].Undone = true
+			undone = events[i]
+			found = true
+			break
+		}
+	}
+	if !found {
+		return undone, state, errNothingToUndo
+	}
+	if undone.Type == "workout" {
+		return undone, state, errUndoWorkout
+	}
+	// Undoing a delete would replay the deleted workout's advance while the
+	// workout itself stays gone
+	if undone.Type == "workout_deleted" {
+		return undone, state, errUndoDeleted
+	}
+
+	if undone.Type == "config" || undone.Type == "rotation" {
+		previous, err := decodeGZCLPConfigSnapshot(undone.Data)
+		if err != nil {
+			return undone, state, err
+		}
+		if err := replaceGZCLPConfig(tx, previous); err != nil {
+			return undone, state, err
+		}
+	}
+
+	if undone.Type == "skip" {
+		if _, err := tx.Exec("DELETE FROM skipped_sessions WHERE event_id = ?", undone.ID); err != nil {
+			return undone, state, err
+		}
+	}
+
+	if undone.Type == "reset" {
+		if _, err := tx.Exec("DELETE FROM gzclp_resets WHERE event_id = ?", undone.ID); err != nil {
+			return undone, state, err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE gzclp_events SET undone = 1 WHERE id = ?", undone.ID); err != nil {
+		return undone, state, err
+	}
+	if state, err = saveGZCLPState(tx, 0); err != nil {
+		return undone, state, err
+	}
+	return undone, state, tx.Commit()
+}
+
+// checkGZCLPState compares the cached state in gzclp_settings with the state
+// replayed from the events, and overwrites the cache when they disagree. It
+// reports whether a repair was needed.
+func checkGZCLPState() (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	events, err := getGZCLPEvents(tx)
+	if err != nil {
+		return false, err
+	}
+	cached, err := loadGZCLPState(tx)
+	if err != nil {
+		return false, err
+	}
+	derived := deriveGZCLPState(events)
+	if cached.CurrentDay == derived.CurrentDay && cached.SkippedDays == derived.SkippedDays &&
+		encodeDays(cached.Upcoming) == encodeDays(derived.Upcoming) {
+		return false, nil
+	}
+	log.Printf("GZCLP settings (day %d, %d skipped, upcoming %v) differ from the event history (day %d, %d skipped, upcoming %v); repairing",
+		cached.CurrentDay, cached.SkippedDays, cached.Upcoming, derived.CurrentDay, derived.SkippedDays, derived.Upcoming)
+	if _, err := saveGZCLPState(tx, 0); err != nil {
+		return true, err
+	}
+	return true, tx.Commit()
+}
+
+func getGZCLPHistoryAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	events, err := getGZCLPEvents(db)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error loading GZCLP events: %v", err)
+		return
+	}
+	state := deriveGZCLPState(events)
+	state.Days = getGZCLPRotationDays(db)
+	json.NewEncoder(w).Encode(GZCLPHistory{State: state, Events: events})
+}
+
+func undoGZCLPEventAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	undone, state, err := undoLastGZCLPEvent()
+	if err == errNothingToUndo || err == errUndoWorkout || err == errUndoDeleted {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error undoing GZCLP event: %v", err)
+		return
+	}
+
+	log.Printf("Undid GZCLP event %d (%s), now on day %d", undone.ID, undone.Type, state.CurrentDay)
+	json.NewEncoder(w).Encode(struct {
+		Undone GZCLPEvent `json:"undone"`
+		State  GZCLPState `json:"state"`
+	}{undone, state})
+}
+
+// Reasons a GZCLP day can be skipped for; empty means none was given.
+var skipReasons = []string{"", "sick", "travel", "time", "other"}
+
+type SkippedSession struct {
+	ID         int      `json:"id"`
+	Date       string   `json:"date"`
+	WorkoutDay int      `json:"workout_day"`
+	Exercises  []string `json:"exercises"`
+	Reason     string   `json:"reason"`
+	Note       string   `json:"note"`
+	EventID    int      `json:"event_id"`
+}
+
+func insertSkippedSession(tx *sql.Tx, skip SkippedSession) error {
+	exercises, err := json.Marshal(skip.Exercises)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO skipped_sessions (date, workout_day, exercises, reason, note, event_id) VALUES (?, ?, ?, ?, ?, ?)",
+		skip.Date, skip.WorkoutDay, string(exercises), skip.Reason, skip.Note, skip.EventID)
+	return err
+}
+
+// getSkippedSessions returns skips between from and to (inclusive, either
+// may be empty), oldest first.
+func getSkippedSessions(from, to string) ([]SkippedSession, error) {
+	query := "SELECT id, date, workout_day, exercises, reason, note, event_id FROM skipped_sessions WHERE 1 = 1"
+	var args []interface{}
+	if from != "" {
+		query += " AND date >= ?"
+		args = append(args, from)
+	}
+	if to != "" {
+		query += " AND date <= ?"
+		args = append(args, to)
+	}
+	query += " ORDER BY date, id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	skips := []SkippedSession{}
+	for rows.Next() {
+		var skip SkippedSession
+		var exercises string
+		if err := rows.Scan(&skip.ID, &skip.Date, &skip.WorkoutDay, &exercises, &skip.Reason, &skip.Note, &skip.EventID); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(exercises), &skip.Exercises)
+		skips = append(skips, skip)
+	}
+	return skips, rows.Err()
+}
+
+type AdherenceWeek struct {
+	WeekStart string  `json:"week_start"`
+	Planned   int     `json:"planned"`
+	Completed int     `json:"completed"`
+	Skipped   int     `json:"skipped"`
+	Missed    int     `json:"missed"` // planned sessions neither logged nor skipped
+	Rate      float64 `json:"rate"`   // completed as a percentage of planned
+}
+
+// AdherenceDay counts the sessions of one GZCLP day. The sessions planned
+// for the whole range are spread over the rotation in order, so earlier days
+// get the remainder when they don't divide evenly.
+type AdherenceDay struct {
+	Day       int     `json:"day"`
+	Planned   int     `json:"planned"`
+	Completed int     `json:"completed"`
+	Skipped   int     `json:"skipped"`
+	Missed    int     `json:"missed"`
+	Rate      float64 `json:"rate"`
+}
+
+type AdherenceReport struct {
+	From            string           `json:"from"`
+	To              string           `json:"to"`
+	SessionsPerWeek int              `json:"sessions_per_week"`
+	Weeks           []AdherenceWeek  `json:"weeks"`
+	Days            []AdherenceDay   `json:"days"`
+	Reasons         map[string]int   `json:"reasons"`
+	Skips           []SkippedSession `json:"skips"`
+}
+
+func adherenceRate(completed, planned int) float64 {
+	if planned == 0 {
+		return 0
+	}
+	return math.Round(float64(completed)/float64(planned)*1000) / 10
+}
+
+// buildAdherenceReport compares logged and skipped GZCLP sessions between
+// from and to against the planned sessions per week.
+func buildAdherenceReport(from, to string, sessionsPerWeek int) (AdherenceReport, error) {
+	report := AdherenceReport{From: from, To: to, SessionsPerWeek: sessionsPerWeek, Reasons: map[string]int{}}
+
+	firstWeek, err := weekStart(from)
+	if err != nil {
+		return report, err
+	}
+	lastWeek, err := weekStart(to)
+	if err != nil {
+		return report, err
+	}
+
+	weeks := map[string]*AdherenceWeek{}
+	start, _ := time.Parse("2006-01-02", firstWeek)
+	end, _ := time.Parse("2006-01-02", lastWeek)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 7) {
+		key := d.Format("2006-01-02")
+		report.Weeks = append(report.Weeks, AdherenceWeek{WeekStart: key, Planned: sessionsPerWeek})
+	}
+	for i := range report.Weeks {
+		weeks[report.Weeks[i].WeekStart] = &report.Weeks[i]
+	}
+
+	days := map[int]*AdherenceDay{}
+	for day := 1; day <= getGZCLPRotationDays(db); day++ {
+		report.Days = append(report.Days, AdherenceDay{Day: day})
+	}
+	for i := range report.Days {
+		days[report.Days[i].Day] = &report.Days[i]
+	}
+
+	rows, err := db.Query("SELECT date, workout_day FROM workouts WHERE workout_type = 'gzclp' AND date >= ? AND date <= ?", from, to)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var date string
+		var day int
+		if err := rows.Scan(&date, &day); err != nil {
+			return report, err
+		}
+		if week, err := weekStart(date); err == nil && weeks[week] != nil {
+			weeks[week].Completed++
+		}
+		if days[day] != nil {
+			days[day].Completed++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	if report.Skips, err = getSkippedSessions(from, to); err != nil {
+		return report, err
+	}
+	for _, skip := range report.Skips {
+		if week, err := weekStart(skip.Date); err == nil && weeks[week] != nil {
+			weeks[week].Skipped++
+		}
+		if days[skip.WorkoutDay] != nil {
+			days[skip.WorkoutDay].Skipped++
+		}
+		reason := skip.Reason
+		if reason == "" {
+			reason = "unspecified"
+		}
+		report.Reasons[reason]++
+	}
+
+	for i := range report.Weeks {
+		week := &report.Weeks[i]
+		week.Missed = week.Planned - week.Completed - week.Skipped
+		if week.Missed < 0 {
+			week.Missed = 0
+		}
+		week.Rate = adherenceRate(week.Completed, week.Planned)
+	}
+	planned := sessionsPerWeek * len(report.Weeks)
+	for i := range report.Days {
+		day := &report.Days[i]
+		day.Planned = planned / len(report.Days)
+		if i < planned%len(report.Days) {
+			day.Planned++
+		}
+		day.Missed = day.Planned - day.Completed - day.Skipped
+		if day.Missed < 0 {
+			day.Missed = 0
+		}
+		day.Rate = adherenceRate(day.Completed, day.Planned)
+	}
+	return report, nil
+}
+
+// getAdherenceAPI serves the adherence report, by default for the last 12
+// weeks up to today.
+func getAdherenceAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	now := time.Now()
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		to = now.Format("2006-01-02")
+	}
+	from := r.URL.Query().Get("from")
+	if from == "" {
+		from = now.AddDate(0, 0, -7*11).Format("2006-01-02")
+		from, _ = weekStart(from)
+	}
+	if _, err := time.Parse("2006-01-02", from); err != nil {
+		http.Error(w, "Invalid from date", http.StatusBadRequest)
+		return
+	}
+	if _, err := time.Parse("2006-01-02", to); err != nil || to < from {
+		http.Error(w, "Invalid to date", http.StatusBadRequest)
+		return
+	}
+
+	report, err := buildAdherenceReport(from, to, getSettings().SessionsPerWeek)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error building adherence report: %v", err)
+		return
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// GZCLPDayChange is a manual change of the next GZCLP day: "jump" to Day,
+// go "back" one day, or "swap" the next day with the one after it, e.g.
+// doing B1 before A1 when the squat rack is taken. A swap queues the
+// skipped day and the normal follow-up so the week ends where it would have.
+type GZCLPDayChange struct {
+	Action string `json:"action"`
+	Day    int    `json:"day"`
+}
+
+func applyGZCLPDayChange(state GZCLPState, change GZCLPDayChange) (GZCLPState, string, error) {
+	current := state.CurrentDay
+	days := state.rotationDays()
+	switch change.Action {
+	case "jump":
+		if change.Day < 1 || change.Day > days {
+			return state, "", fmt.Errorf("day must be between 1 and %d", days)
+		}
+		state.CurrentDay, state.Upcoming = change.Day, nil
+		return state, fmt.Sprintf("Jumped from day %d to day %d", current, change.Day), nil
+	case "back":
+		state.CurrentDay, state.Upcoming = ((current+days-2)%days)+1, nil
+		return state, fmt.Sprintf("Went back from day %d to day %d", current, state.CurrentDay), nil
+	case "swap":
+		next, _ := state.advance(current)
+		if next == current {
+			return state, "", fmt.Errorf("nothing to swap with")
+		}
+		after := (next % days) + 1
+		if len(state.Upcoming) > 1 {
+			after = state.Upcoming[1]
+		}
+		state.CurrentDay, state.Upcoming = next, []int{current, after}
+		return state, fmt.Sprintf("Swapped day %d with day %d", current, next), nil
+	}
+	return state, "", fmt.Errorf("action must be jump, back or swap")
+}
+
+func changeGZCLPDayAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var change GZCLPDayChange
+	if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	state, err := loadGZCLPState(tx)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error loading GZCLP state: %v", err)
+		return
+	}
+	from := state.CurrentDay
+	state, detail, err := applyGZCLPDayChange(state, change)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, state, err = applyGZCLPEvent(tx, GZCLPEvent{Type: "day_change", FromDay: from, ToDay: state.CurrentDay, Detail: detail, Data: encodeDays(state.Upcoming)})
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		log.Printf("Error changing GZCLP day: %v", err)
+		return
+	}
+
+	log.Printf("%s", detail)
+	json.NewEncoder(w).Encode(state)
+}
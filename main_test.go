@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -28,17 +29,29 @@ func setupTestDB(t *testing.T) {
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		date TEXT NOT NULL,
 		workout_type TEXT DEFAULT 'custom',
-		workout_day INTEGER DEFAULT 0
+		workout_day INTEGER DEFAULT 0,
+		is_deload INTEGER NOT NULL DEFAULT 0,
+		expected_day INTEGER NOT NULL DEFAULT 0,
+		client_id TEXT
 	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_workouts_client_id ON workouts(client_id);
 	CREATE TABLE IF NOT EXISTS exercise_library (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		name TEXT NOT NULL UNIQUE,
-		is_default INTEGER NOT NULL DEFAULT 0
+		is_default INTEGER NOT NULL DEFAULT 0,
+		primary_muscles TEXT NOT NULL DEFAULT '',
+		secondary_muscles TEXT NOT NULL DEFAULT '',
+		equipment TEXT NOT NULL DEFAULT '',
+		movement_pattern TEXT NOT NULL DEFAULT '',
+		increment REAL NOT NULL DEFAULT 0
 	);
 	CREATE TABLE IF NOT EXISTS exercises (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		workout_id INTEGER,
 		name TEXT NOT NULL,
+		slot TEXT NOT NULL DEFAULT '',
+		tier TEXT NOT NULL DEFAULT '',
+		stage TEXT NOT NULL DEFAULT '',
 		FOREIGN KEY(workout_id) REFERENCES workouts(id)
 	);
 	CREATE TABLE IF NOT EXISTS sets (
@@ -52,6 +65,8 @@ func setupTestDB(t *testing.T) {
 		id INTEGER PRIMARY KEY DEFAULT 1,
 		current_day INTEGER NOT NULL DEFAULT 1,
 		skipped_days INTEGER NOT NULL DEFAULT 0,
+		upcoming_days TEXT NOT NULL DEFAULT '[]',
+		rotation_days INTEGER NOT NULL DEFAULT 4,
 		CONSTRAINT single_row CHECK (id = 1)
 	);
 	CREATE TABLE IF NOT EXISTS gzclp_day_exercises (
@@ -59,8 +74,112 @@ func setupTestDB(t *testing.T) {
 		day INTEGER NOT NULL,
 		slot TEXT NOT NULL,
 		exercise_name TEXT NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		tier TEXT NOT NULL DEFAULT '',
+		sets INTEGER NOT NULL DEFAULT 0,
+		reps INTEGER NOT NULL DEFAULT 0,
+		percent REAL NOT NULL DEFAULT 0,
 		UNIQUE(day, slot)
-	);`
+	);
+	CREATE TABLE IF NOT EXISTS measurement_types (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		unit TEXT NOT NULL DEFAULT 'cm',
+		is_default INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS measurements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type_id INTEGER NOT NULL,
+		date TEXT NOT NULL,
+		value REAL NOT NULL,
+		FOREIGN KEY(type_id) REFERENCES measurement_types(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS workout_templates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE
+	);
+
+	CREATE TABLE IF NOT EXISTS template_exercises (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		template_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		exercise_name TEXT NOT NULL,
+		sets INTEGER NOT NULL,
+		reps INTEGER NOT NULL,
+		weight REAL NOT NULL DEFAULT 0,
+		percent REAL NOT NULL DEFAULT 0,
+		FOREIGN KEY(template_id) REFERENCES workout_templates(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS gzclp_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_type TEXT NOT NULL,
+		from_day INTEGER NOT NULL DEFAULT 0,
+		to_day INTEGER NOT NULL DEFAULT 0,
+		workout_id INTEGER NOT NULL DEFAULT 0,
+		detail TEXT NOT NULL DEFAULT '',
+		data TEXT NOT NULL DEFAULT '',
+		undone INTEGER NOT NULL DEFAULT 0,
+		created_at TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS skipped_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date TEXT NOT NULL,
+		workout_day INTEGER NOT NULL,
+		exercises TEXT NOT NULL DEFAULT '[]',
+		reason TEXT NOT NULL DEFAULT '',
+		note TEXT NOT NULL DEFAULT '',
+		event_id INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS workout_drafts (
+		form TEXT PRIMARY KEY,
+		data TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS personal_records (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workout_id INTEGER NOT NULL,
+		exercise_name TEXT NOT NULL,
+		record_type TEXT NOT NULL,
+		reps INTEGER NOT NULL DEFAULT 0,
+		value REAL NOT NULL,
+		previous_value REAL NOT NULL DEFAULT 0,
+		date TEXT NOT NULL,
+		FOREIGN KEY(workout_id) REFERENCES workouts(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS training_maxes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		exercise_name TEXT NOT NULL,
+		value REAL NOT NULL,
+		date TEXT NOT NULL,
+		note TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS gzclp_resets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		exercise_name TEXT NOT NULL,
+		tier TEXT NOT NULL,
+		workout_id INTEGER NOT NULL,
+		failed_stage TEXT NOT NULL,
+		failed_weight REAL NOT NULL,
+		test_weight REAL NOT NULL DEFAULT 0,
+		new_weight REAL NOT NULL,
+		dismissed INTEGER NOT NULL DEFAULT 0,
+		date TEXT NOT NULL,
+		event_id INTEGER NOT NULL DEFAULT 0,
+		after_workout_id INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_gzclp_resets_failure ON gzclp_resets(tier, exercise_name, workout_id);`
 
 	_, err = db.Exec(createTables)
 	if err != nil {
@@ -83,7 +202,7 @@ func seedWorkout(t *testing.T, date, workoutType string, workoutDay int, exercis
 		WorkoutDay:  workoutDay,
 		Exercises:   exercises,
 	}
-	err := saveWorkoutToDB(w)
+	_, err := saveWorkoutToDB(w)
 	if err != nil {
 		t.Fatalf("failed to seed workout: %v", err)
 	}
@@ -205,7 +324,7 @@ func TestSaveAndGetWorkouts(t *testing.T) {
 		},
 	}
 
-	err := saveWorkoutToDB(workout)
+	_, err := saveWorkoutToDB(workout)
 	if err != nil {
 		t.Fatalf("failed to save workout: %v", err)
 	}
@@ -260,7 +379,7 @@ func TestSaveWorkout_GZCLPType(t *testing.T) {
 		},
 	}
 
-	err := saveWorkoutToDB(workout)
+	_, err := saveWorkoutToDB(workout)
 	if err != nil {
 		t.Fatalf("failed to save workout: %v", err)
 	}
@@ -322,48 +441,43 @@ func TestGetNextGZCLPWorkoutDay_AfterUpdate(t *testing.T) {
 	}
 }
 
-func TestGetGZCLPExercises(t *testing.T) {
+func TestGetGZCLPDaySlots(t *testing.T) {
 	setupTestDB(t)
 	populateDefaultGZCLPDayExercises()
 
 	tests := []struct {
-		day                                    int
-		wantT1, wantT2, wantT3, wantA1, wantA2 string
+		day  int
+		want []string
 	}{
-		{1, "Squat", "Bench Press", "Lat Pulldown", "Leg Press", "Chest Fly"},
-		{2, "Overhead Press", "Deadlift", "Bent Over Row", "Lateral Raise", "Leg Curl"},
-		{3, "Bench Press", "Squat", "Lat Pulldown", "Chest Fly", "Leg Press"},
-		{4, "Deadlift", "Overhead Press", "Bent Over Row", "Leg Curl", "Lateral Raise"},
+		{1, []string{"Squat", "Bench Press", "Lat Pulldown", "Leg Press", "Chest Fly"}},
+		{2, []string{"Overhead Press", "Deadlift", "Bent Over Row", "Lateral Raise", "Leg Curl"}},
+		{3, []string{"Bench Press", "Squat", "Lat Pulldown", "Chest Fly", "Leg Press"}},
+		{4, []string{"Deadlift", "Overhead Press", "Bent Over Row", "Leg Curl", "Lateral Raise"}},
 	}
 
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("Day%d", tt.day), func(t *testing.T) {
-			t1, t2, t3, a1, a2 := getGZCLPExercises(tt.day)
-			if t1 != tt.wantT1 {
-				t.Errorf("T1: got %q, want %q", t1, tt.wantT1)
+			slots := getGZCLPDaySlots(tt.day)
+			got := slotExerciseNames(slots)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", got, tt.want)
 			}
-			if t2 != tt.wantT2 {
-				t.Errorf("T2: got %q, want %q", t2, tt.wantT2)
-			}
-			if t3 != tt.wantT3 {
-				t.Errorf("T3: got %q, want %q", t3, tt.wantT3)
-			}
-			if a1 != tt.wantA1 {
-				t.Errorf("Additional1: got %q, want %q", a1, tt.wantA1)
-			}
-			if a2 != tt.wantA2 {
-				t.Errorf("Additional2: got %q, want %q", a2, tt.wantA2)
+			if slots[0].Tier != "T1" || slots[1].Tier != "T2" || slots[2].Tier != "T3" || slots[3].Tier != "" {
+				t.Errorf("unexpected tiers: %+v", slots)
 			}
 		})
 	}
 }
 
-func TestGetGZCLPExercises_FallbackDefaults(t *testing.T) {
+func TestGetGZCLPDaySlots_FallbackDefaults(t *testing.T) {
 	setupTestDB(t)
-	// No day exercises populated — should return hardcoded defaults
-	t1, t2, t3, a1, a2 := getGZCLPExercises(99)
-	if t1 != "Squat" || t2 != "Bench Press" || t3 != "Lat Pulldown" || a1 != "Leg Press" || a2 != "Chest Fly" {
-		t.Errorf("unexpected fallback defaults: %s, %s, %s, %s, %s", t1, t2, t3, a1, a2)
+	// No day exercises populated — should return the default layout
+	got := slotExerciseNames(getGZCLPDaySlots(1))
+	if strings.Join(got, ",") != "Squat,Bench Press,Lat Pulldown,Leg Press,Chest Fly" {
+		t.Errorf("unexpected fallback defaults: %v", got)
+	}
+	if slots := getGZCLPDaySlots(99); len(slots) != 0 {
+		t.Errorf("expected no slots for a day outside the default layout, got %v", slots)
 	}
 }
 
@@ -1017,6 +1131,8 @@ func TestExercisesAPI_PUT_UpdatesGZCLPReferences(t *testing.T) {
 	// Insert custom exercise and assign to GZCLP
 	db.Exec("INSERT INTO exercise_library (name, is_default) VALUES ('Custom Lift', 0)")
 	db.Exec("INSERT INTO gzclp_day_exercises (day, slot, exercise_name) VALUES (1, 'T1', 'Custom Lift')")
+	db.Exec("INSERT INTO workout_templates (id, name) VALUES (1, 'Heavy')")
+	db.Exec("INSERT INTO template_exercises (template_id, position, exercise_name, sets, reps) VALUES (1, 0, 'Custom Lift', 5, 5)")
 
 	var id int
 	db.QueryRow("SELECT id FROM exercise_library WHERE name = 'Custom Lift'").Scan(&id)
@@ -1037,6 +1153,33 @@ func TestExercisesAPI_PUT_UpdatesGZCLPReferences(t *testing.T) {
 	if gzclpName != "Renamed Lift" {
 		t.Errorf("expected GZCLP reference to update to 'Renamed Lift', got %q", gzclpName)
 	}
+	var templateName string
+	db.QueryRow("SELECT exercise_name FROM template_exercises WHERE template_id = 1").Scan(&templateName)
+	if templateName != "Renamed Lift" {
+		t.Errorf("expected template reference to update to 'Renamed Lift', got %q", templateName)
+	}
+}
+
+func TestExercisesAPI_PUT_KeepsGZCLPResets(t *testing.T) {
+	setupTestDB(t)
+	db.Exec("INSERT INTO exercise_library (name, is_default) VALUES ('Custom Lift', 0)")
+	seedWorkout(t, "2026-03-02", "gzclp", 1, []Exercise{{Name: "Custom Lift", Tier: "T1", Stage: "10x1", Sets: tenSingles(120, 0)}})
+	if w := postGZCLPReset(t, `{"exercise_name": "Custom Lift", "tier": "T1", "test_weight": 110}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var id int
+	db.QueryRow("SELECT id FROM exercise_library WHERE name = 'Custom Lift'").Scan(&id)
+	req := httptest.NewRequest("PUT", "/api/exercises", strings.NewReader(fmt.Sprintf(`{"id": %d, "name": "Renamed Lift"}`, id)))
+	w := httptest.NewRecorder()
+	handleExercisesAPI(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	if status := getGZCLPResetStatusAPI(t); len(status.Pending) != 0 || len(status.History) != 1 {
+		t.Errorf("expected the old failure to stay reset after the rename, got %+v", status)
+	}
 }
 
 func TestExercisesAPI_DELETE(t *testing.T) {
@@ -1112,10 +1255,13 @@ func TestGZCLPConfigAPI_GET(t *testing.T) {
 		t.Errorf("expected 200, got %d", w.Code)
 	}
 
-	var assignments []GZCLPDayExercise
-	json.NewDecoder(w.Body).Decode(&assignments)
-	if len(assignments) != 20 {
-		t.Errorf("expected 20 assignments, got %d", len(assignments))
+	var config GZCLPConfig
+	json.NewDecoder(w.Body).Decode(&config)
+	if config.Days != 4 {
+		t.Errorf("expected a 4-day rotation, got %d", config.Days)
+	}
+	if len(config.Slots) != 20 {
+		t.Errorf("expected 20 slots, got %d", len(config.Slots))
 	}
 }
 
@@ -1482,3 +1628,2900 @@ func TestE2E_LatestExerciseAfterMultipleWorkouts(t *testing.T) {
 		t.Errorf("expected second set weight 85, got %.1f", result.Sets[1].Weight)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Measurements
+// ---------------------------------------------------------------------------
+
+func TestPopulateDefaultMeasurementTypes(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultMeasurementTypes()
+	populateDefaultMeasurementTypes() // idempotent
+
+	types, err := getMeasurementTypes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(types) != 6 {
+		t.Fatalf("expected 6 default measurement types, got %d", len(types))
+	}
+	for _, mt := range types {
+		if !mt.IsDefault {
+			t.Errorf("expected %q to be default", mt.Name)
+		}
+	}
+}
+
+func TestMeasurementTypesAPI_POSTAndDELETE(t *testing.T) {
+	setupTestDB(t)
+
+	req := httptest.NewRequest("POST", "/api/measurement-types", strings.NewReader(`{"name":"Neck"}`))
+	w := httptest.NewRecorder()
+	handleMeasurementTypesAPI(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var created MeasurementType
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.ID == 0 || created.Unit != "cm" {
+		t.Errorf("expected created type with default unit cm, got %+v", created)
+	}
+
+	db.Exec("INSERT INTO measurements (type_id, date, value) VALUES (?, '2026-03-01', 38)", created.ID)
+
+	req = httptest.NewRequest("DELETE", fmt.Sprintf("/api/measurement-types?id=%d", created.ID), nil)
+	w = httptest.NewRecorder()
+	handleMeasurementTypesAPI(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM measurements").Scan(&count)
+	if count != 0 {
+		t.Errorf("expected history to be deleted with its type, got %d rows", count)
+	}
+}
+
+func TestMeasurementTypesAPI_DELETE_DefaultProtected(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultMeasurementTypes()
+
+	var id int
+	db.QueryRow("SELECT id FROM measurement_types WHERE name = 'Waist'").Scan(&id)
+
+	req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/measurement-types?id=%d", id), nil)
+	w := httptest.NewRecorder()
+	handleMeasurementTypesAPI(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestMeasurementsAPI_POSTAndGET(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultMeasurementTypes()
+
+	var waistID, armID int
+	db.QueryRow("SELECT id FROM measurement_types WHERE name = 'Waist'").Scan(&waistID)
+	db.QueryRow("SELECT id FROM measurement_types WHERE name = 'Arm'").Scan(&armID)
+
+	for _, body := range []string{
+		fmt.Sprintf(`{"type_id":%d,"date":"2026-03-15","value":82.5}`, waistID),
+		fmt.Sprintf(`{"type_id":%d,"date":"2026-03-01","value":84}`, waistID),
+		fmt.Sprintf(`{"type_id":%d,"date":"2026-03-01","value":38}`, armID),
+	} {
+		req := httptest.NewRequest("POST", "/api/measurements", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handleMeasurementsAPI(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/measurements?type_id=%d", waistID), nil)
+	w := httptest.NewRecorder()
+	handleMeasurementsAPI(w, req)
+
+	var history []Measurement
+	json.NewDecoder(w.Body).Decode(&history)
+	if len(history) != 2 {
+		t.Fatalf("expected 2 waist measurements, got %d", len(history))
+	}
+	if history[0].Date != "2026-03-01" || history[1].Value != 82.5 {
+		t.Errorf("expected history ordered by date, got %+v", history)
+	}
+	if history[0].Type != "Waist" || history[0].Unit != "cm" {
+		t.Errorf("expected type name and unit to be joined, got %+v", history[0])
+	}
+}
+
+func TestMeasurementsAPI_POST_Validation(t *testing.T) {
+	setupTestDB(t)
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`not json`, http.StatusBadRequest},
+		{`{"date":"2026-03-01","value":80}`, http.StatusBadRequest},
+		{`{"type_id":1,"date":"2026-03-01","value":0}`, http.StatusBadRequest},
+		{`{"type_id":999,"date":"2026-03-01","value":80}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/api/measurements", strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		handleMeasurementsAPI(w, req)
+		if w.Code != tt.want {
+			t.Errorf("body %s: expected %d, got %d", tt.body, tt.want, w.Code)
+		}
+	}
+}
+
+func TestMeasurementsAPI_DELETE_NotFound(t *testing.T) {
+	setupTestDB(t)
+
+	req := httptest.NewRequest("DELETE", "/api/measurements?id=42", nil)
+	w := httptest.NewRecorder()
+	handleMeasurementsAPI(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestMeasurementsPageHandler(t *testing.T) {
+	setupTestDB(t)
+
+	req := httptest.NewRequest("GET", "/measurements", nil)
+	w := httptest.NewRecorder()
+	measurementsPage(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestExportData(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultMeasurementTypes()
+	seedWorkout(t, "2026-03-15", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+	})
+	db.Exec("INSERT INTO measurements (type_id, date, value) SELECT id, '2026-03-15', 80 FROM measurement_types WHERE name = 'Body Weight'")
+
+	req := httptest.NewRequest("GET", "/api/export", nil)
+	w := httptest.NewRecorder()
+	exportData(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), "attachment") {
+		t.Error("export should be served as an attachment")
+	}
+
+	var export ExportData
+	json.NewDecoder(w.Body).Decode(&export)
+	if len(export.Workouts) != 1 {
+		t.Errorf("expected 1 workout in export, got %d", len(export.Workouts))
+	}
+	if len(export.MeasurementTypes) != 6 {
+		t.Errorf("expected 6 measurement types in export, got %d", len(export.MeasurementTypes))
+	}
+	if len(export.Measurements) != 1 || export.Measurements[0].Type != "Body Weight" {
+		t.Errorf("expected body weight measurement in export, got %+v", export.Measurements)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Exercise metadata
+// ---------------------------------------------------------------------------
+
+func TestPopulateDefaultExercises_Metadata(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+
+	exercises, _ := getAllExercises()
+	for _, e := range exercises {
+		if len(e.PrimaryMuscles) == 0 || e.Equipment == "" || e.MovementPattern == "" {
+			t.Errorf("expected default exercise %q to have metadata, got %+v", e.Name, e)
+		}
+		if msg := validateExerciseMetadata(e); msg != "" {
+			t.Errorf("default exercise %q has invalid metadata: %s", e.Name, msg)
+		}
+	}
+}
+
+func TestPopulateDefaultExercises_KeepsEditedMetadata(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+	db.Exec("UPDATE exercise_library SET equipment = 'machine' WHERE name = 'Squat'")
+	populateDefaultExercises()
+
+	var equipment string
+	db.QueryRow("SELECT equipment FROM exercise_library WHERE name = 'Squat'").Scan(&equipment)
+	if equipment != "machine" {
+		t.Errorf("expected user-edited equipment to survive repopulation, got %q", equipment)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	if got := splitList(""); got == nil || len(got) != 0 {
+		t.Errorf("expected empty non-nil slice, got %#v", got)
+	}
+	got := splitList("quads, glutes,")
+	if len(got) != 2 || got[0] != "quads" || got[1] != "glutes" {
+		t.Errorf("unexpected split result: %#v", got)
+	}
+}
+
+func TestExercisesAPI_POST_WithMetadata(t *testing.T) {
+	setupTestDB(t)
+
+	body := `{"name":"Cable Row","primary_muscles":["back"],"secondary_muscles":["biceps"],"equipment":"cable","movement_pattern":"horizontal_pull"}`
+	req := httptest.NewRequest("POST", "/api/exercises", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handleExercisesAPI(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	exercises, _ := getAllExercises()
+	if len(exercises) != 1 {
+		t.Fatalf("expected 1 exercise, got %d", len(exercises))
+	}
+	e := exercises[0]
+	if len(e.PrimaryMuscles) != 1 || e.PrimaryMuscles[0] != "back" || e.Equipment != "cable" || e.MovementPattern != "horizontal_pull" {
+		t.Errorf("metadata not stored: %+v", e)
+	}
+}
+
+func TestExercisesAPI_POST_InvalidMetadata(t *testing.T) {
+	setupTestDB(t)
+
+	for _, body := range []string{
+		`{"name":"X","primary_muscles":["wings"]}`,
+		`{"name":"X","equipment":"kettlebell"}`,
+		`{"name":"X","movement_pattern":"carry"}`,
+	} {
+		req := httptest.NewRequest("POST", "/api/exercises", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handleExercisesAPI(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body %s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestExercisesAPI_PUT_DefaultMetadataEditable(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+
+	var id int
+	db.QueryRow("SELECT id FROM exercise_library WHERE name = 'Leg Press'").Scan(&id)
+
+	body := fmt.Sprintf(`{"id": %d, "name": "Leg Press", "primary_muscles": ["quads"], "equipment": "machine", "movement_pattern": "squat"}`, id)
+	req := httptest.NewRequest("PUT", "/api/exercises", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handleExercisesAPI(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for metadata-only edit of default exercise, got %d", w.Code)
+	}
+	var primary string
+	db.QueryRow("SELECT primary_muscles FROM exercise_library WHERE id = ?", id).Scan(&primary)
+	if primary != "quads" {
+		t.Errorf("expected primary muscles 'quads', got %q", primary)
+	}
+}
+
+func TestExercisesAPI_GET_Filters(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"equipment=barbell", 7},
+		{"muscle=calves", 1},
+		{"muscle=biceps", 3}, // Bicep Curl, plus Lat Pulldown and Bent Over Row as secondary
+		{"movement_pattern=hinge", 2},
+		{"muscle=back&equipment=cable", 1},
+		{"equipment=bodyweight", 0},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/exercises?"+tt.query, nil)
+		w := httptest.NewRecorder()
+		handleExercisesAPI(w, req)
+
+		var exercises []ExerciseDB
+		json.NewDecoder(w.Body).Decode(&exercises)
+		if len(exercises) != tt.want {
+			t.Errorf("%s: expected %d exercises, got %d", tt.query, tt.want, len(exercises))
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Muscle group report
+// ---------------------------------------------------------------------------
+
+func TestWeekStart(t *testing.T) {
+	tests := map[string]string{
+		"2026-03-16": "2026-03-16", // Monday
+		"2026-03-18": "2026-03-16", // Wednesday
+		"2026-03-22": "2026-03-16", // Sunday
+		"2026-03-01": "2026-02-23", // Sunday across a month boundary
+	}
+	for date, want := range tests {
+		got, err := weekStart(date)
+		if err != nil || got != want {
+			t.Errorf("weekStart(%s) = %s, %v; want %s", date, got, err, want)
+		}
+	}
+	if _, err := weekStart("not-a-date"); err == nil {
+		t.Error("expected error for invalid date")
+	}
+}
+
+func TestBuildMuscleGroupReport(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+
+	seedWorkout(t, "2026-03-16", "custom", 0, []Exercise{
+		{Name: "Bent Over Row", Sets: []Set{{Weight: 60, Reps: 10}, {Weight: 60, Reps: 10}}},
+	})
+	seedWorkout(t, "2026-03-19", "custom", 0, []Exercise{
+		{Name: "Lat Pulldown", Sets: []Set{{Weight: 50, Reps: 12}}},
+		{Name: "Mystery Machine", Sets: []Set{{Weight: 20, Reps: 10}}},
+	})
+	seedWorkout(t, "2026-03-23", "custom", 0, []Exercise{
+		{Name: "Bicep Curl", Sets: []Set{{Weight: 12, Reps: 12}, {Weight: 12, Reps: 0}}},
+	})
+
+	report, err := buildMuscleGroupReport("", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Weeks) != 2 {
+		t.Fatalf("expected 2 weeks, got %d", len(report.Weeks))
+	}
+
+	first := report.Weeks[0]
+	if first.WeekStart != "2026-03-16" {
+		t.Errorf("expected first week 2026-03-16, got %s", first.WeekStart)
+	}
+	back := first.MuscleGroups["back"]
+	if back.Sets != 3 || back.Tonnage != 1800 {
+		t.Errorf("expected back 3 sets / 1800 kg, got %+v", back)
+	}
+	// Row has biceps + shoulders secondary, pulldown has biceps secondary
+	if first.MuscleGroups["biceps"].Sets != 1.5 || first.MuscleGroups["biceps"].Tonnage != 0 {
+		t.Errorf("expected biceps 1.5 secondary sets without tonnage, got %+v", first.MuscleGroups["biceps"])
+	}
+	// The 0-rep curl set is not a hard set
+	if report.Weeks[1].MuscleGroups["biceps"].Sets != 1 {
+		t.Errorf("expected 1 biceps set in second week, got %+v", report.Weeks[1].MuscleGroups["biceps"])
+	}
+	if len(report.Unclassified) != 1 || report.Unclassified[0] != "Mystery Machine" {
+		t.Errorf("expected Mystery Machine to be unclassified, got %v", report.Unclassified)
+	}
+}
+
+func TestMuscleGroupStatisticsAPI_DateRange(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+
+	seedWorkout(t, "2026-03-02", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+	})
+	seedWorkout(t, "2026-03-16", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+	})
+
+	req := httptest.NewRequest("GET", "/api/statistics/muscle-groups?from=2026-03-10&to=2026-03-31", nil)
+	w := httptest.NewRecorder()
+	getMuscleGroupStatistics(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var report MuscleGroupReport
+	json.NewDecoder(w.Body).Decode(&report)
+	if len(report.Weeks) != 1 || report.Weeks[0].WeekStart != "2026-03-16" {
+		t.Errorf("expected only the week of 2026-03-16, got %+v", report.Weeks)
+	}
+	// Squat tonnage is split between its two primary muscles
+	quads, glutes := report.Weeks[0].MuscleGroups["quads"], report.Weeks[0].MuscleGroups["glutes"]
+	if quads.Tonnage != 250 || glutes.Tonnage != 250 {
+		t.Errorf("expected 250 kg each for quads and glutes, got %+v / %+v", quads, glutes)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Personal records
+// ---------------------------------------------------------------------------
+
+func TestSaveWorkout_FirstSessionSetsNoRecords(t *testing.T) {
+	setupTestDB(t)
+
+	result, err := saveWorkoutToDB(Workout{
+		Date:        "2026-03-01",
+		WorkoutType: "custom",
+		Exercises:   []Exercise{{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}}},
+	})
+	if err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if result.WorkoutID == 0 {
+		t.Error("expected workout ID to be returned")
+	}
+	if len(result.Records) != 0 {
+		t.Errorf("expected no records without history, got %+v", result.Records)
+	}
+}
+
+func TestSaveWorkout_DetectsRecords(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-01", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}, {Weight: 100, Reps: 5}, {Weight: 110, Reps: 3}}},
+	})
+
+	result, err := saveWorkoutToDB(Workout{
+		Date:        "2026-03-08",
+		WorkoutType: "custom",
+		Exercises: []Exercise{
+			{Name: "Squat", Sets: []Set{{Weight: 105, Reps: 5}, {Weight: 105, Reps: 5}, {Weight: 105, Reps: 5}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	found := make(map[string]PersonalRecord)
+	for _, rec := range result.Records {
+		found[rec.RecordType] = rec
+	}
+	if rec, ok := found["rep_max"]; !ok || rec.Reps != 5 || rec.Value != 105 || rec.PreviousValue != 100 {
+		t.Errorf("expected 5-rep max 105 over 100, got %+v", found["rep_max"])
+	}
+	if _, ok := found["e1rm"]; !ok {
+		t.Error("expected e1RM record")
+	}
+	if rec, ok := found["volume"]; !ok || rec.Value != 1575 || rec.PreviousValue != 1330 {
+		t.Errorf("expected volume 1575 over 1330, got %+v", found["volume"])
+	}
+
+	stored, _ := getPersonalRecords("Squat", 0)
+	if len(stored) != len(result.Records) {
+		t.Errorf("expected %d stored records, got %d", len(result.Records), len(stored))
+	}
+}
+
+func TestSaveWorkout_HeavierLowerRepsIsNotRepMax(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-01", "custom", 0, []Exercise{
+		{Name: "Bench Press", Sets: []Set{{Weight: 80, Reps: 5}}},
+	})
+
+	// 75x3 is beaten by the earlier 80x5, so it is no 3-rep PR
+	result, _ := saveWorkoutToDB(Workout{
+		Date:      "2026-03-08",
+		Exercises: []Exercise{{Name: "Bench Press", Sets: []Set{{Weight: 75, Reps: 3}}}},
+	})
+	if len(result.Records) != 0 {
+		t.Errorf("expected no records, got %+v", result.Records)
+	}
+}
+
+func TestCreateWorkout_JSONResponseIncludesRecords(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-01", "custom", 0, []Exercise{
+		{Name: "Deadlift", Sets: []Set{{Weight: 140, Reps: 5}}},
+	})
+
+	form := url.Values{}
+	form.Set("date", "2026-03-08")
+	form.Set("workout_type", "custom")
+	form.Set("exercise_0", "Deadlift")
+	form.Set("reps_0_0", "5")
+	form.Set("weight_0_0", "150")
+
+	req := httptest.NewRequest("POST", "/workout/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	createWorkout(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var result SaveWorkoutResult
+	json.NewDecoder(w.Body).Decode(&result)
+	if result.WorkoutID == 0 || len(result.Records) == 0 {
+		t.Errorf("expected workout ID and records, got %+v", result)
+	}
+}
+
+func TestCreateWorkout_RedirectsWithRecords(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-01", "custom", 0, []Exercise{
+		{Name: "Deadlift", Sets: []Set{{Weight: 140, Reps: 5}}},
+	})
+
+	form := url.Values{}
+	form.Set("date", "2026-03-08")
+	form.Set("exercise_0", "Deadlift")
+	form.Set("reps_0_0", "5")
+	form.Set("weight_0_0", "150")
+
+	req := httptest.NewRequest("POST", "/workout/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	createWorkout(w, req)
+
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); !strings.HasPrefix(loc, "/?prs=") {
+		t.Errorf("expected redirect to PR celebration, got %q", loc)
+	}
+}
+
+func TestDeleteWorkout_RemovesRecords(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-01", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+	})
+	id := seedWorkout(t, "2026-03-08", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 110, Reps: 5}}},
+	})
+
+	form := url.Values{}
+	form.Set("id", fmt.Sprintf("%d", id))
+	req := httptest.NewRequest("POST", "/workout/delete", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	deleteWorkout(w, req)
+
+	if records, _ := getPersonalRecords("", 0); len(records) != 0 {
+		t.Errorf("expected records to be deleted with workout, got %+v", records)
+	}
+}
+
+func TestRecordsAPI_RepMaxes(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-01", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 3}, {Weight: 120, Reps: 1}}},
+	})
+
+	req := httptest.NewRequest("GET", "/api/records?exercise=Squat", nil)
+	w := httptest.NewRecorder()
+	handleRecordsAPI(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp RecordsResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.RepMaxes) != 3 {
+		t.Fatalf("expected rep maxes for 1-3 reps, got %+v", resp.RepMaxes)
+	}
+	if resp.RepMaxes[0].Weight != 120 || resp.RepMaxes[1].Weight != 100 || resp.RepMaxes[2].Weight != 100 {
+		t.Errorf("unexpected rep maxes: %+v", resp.RepMaxes)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// 1RM formulas
+// ---------------------------------------------------------------------------
+
+func TestEstimate1RM_Formulas(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    float64
+	}{
+		{"brzycki", 112.5},
+		{"epley", 116.67},
+		{"lombardi", 117.46},
+		{"mayhew", 119.01},
+		{"wathan", 116.58},
+		{"average", 116.44},
+		{"unknown", 112.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.formula, func(t *testing.T) {
+			got := estimate1RM(100, 5, tt.formula)
+			if diff := got - tt.want; diff < -0.01 || diff > 0.01 {
+				t.Errorf("estimate1RM(100, 5, %q) = %.2f, want %.2f", tt.formula, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimate1RM_HighRepsCapped(t *testing.T) {
+	for _, formula := range oneRMFormulas {
+		capped := estimate1RM(50, maxEstimateReps, formula)
+		for _, reps := range []int{20, 37, 50} {
+			got := estimate1RM(50, reps, formula)
+			if got != capped || got <= 0 {
+				t.Errorf("%s: estimate1RM(50, %d) = %.2f, want capped %.2f", formula, reps, got, capped)
+			}
+		}
+	}
+	if got := estimate1RM(50, 0, "epley"); got != 0 {
+		t.Errorf("expected 0 for zero reps, got %.2f", got)
+	}
+}
+
+func TestSettingsAPI(t *testing.T) {
+	setupTestDB(t)
+
+	req := httptest.NewRequest("GET", "/api/settings", nil)
+	w := httptest.NewRecorder()
+	handleSettingsAPI(w, req)
+	var settings Settings
+	json.NewDecoder(w.Body).Decode(&settings)
+	if settings.OneRMFormula != "brzycki" {
+		t.Errorf("expected brzycki default, got %q", settings.OneRMFormula)
+	}
+
+	req = httptest.NewRequest("PUT", "/api/settings", strings.NewReader(`{"one_rm_formula": "epley"}`))
+	w = httptest.NewRecorder()
+	handleSettingsAPI(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := getDefaultOneRMFormula(); got != "epley" {
+		t.Errorf("expected epley after PUT, got %q", got)
+	}
+
+	req = httptest.NewRequest("PUT", "/api/settings", strings.NewReader(`{"one_rm_formula": "guesswork"}`))
+	w = httptest.NewRecorder()
+	handleSettingsAPI(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown formula, got %d", w.Code)
+	}
+}
+
+func TestStatisticsAPI_FormulaParam(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-01", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+	})
+
+	req := httptest.NewRequest("GET", "/api/statistics?exercise=Squat&formula=epley", nil)
+	w := httptest.NewRecorder()
+	getStatisticsData(w, req)
+
+	var resp StatisticsResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Formula != "epley" || len(resp.Data) != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if diff := resp.Data[0].Estimated1RM - 116.67; diff < -0.01 || diff > 0.01 {
+		t.Errorf("expected Epley estimate 116.67, got %.2f", resp.Data[0].Estimated1RM)
+	}
+
+	req = httptest.NewRequest("GET", "/api/statistics?exercise=Squat&formula=guesswork", nil)
+	w = httptest.NewRecorder()
+	getStatisticsData(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown formula, got %d", w.Code)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Statistics aggregation
+// ---------------------------------------------------------------------------
+
+func TestStatisticsAPI_SameDaySessionsKeptApart(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-10", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+	})
+	seedWorkout(t, "2026-03-10", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 60, Reps: 10}}},
+	})
+
+	req := httptest.NewRequest("GET", "/api/statistics?exercise=Squat", nil)
+	w := httptest.NewRecorder()
+	getStatisticsData(w, req)
+
+	var resp StatisticsResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Aggregation != "session" || len(resp.Data) != 2 {
+		t.Fatalf("expected 2 session points, got %+v", resp)
+	}
+	if resp.Data[0].WorkoutID == resp.Data[1].WorkoutID {
+		t.Error("expected distinct workout IDs per session")
+	}
+	if resp.Data[0].TotalVolume != 500 || resp.Data[1].TotalVolume != 600 {
+		t.Errorf("unexpected volumes: %+v", resp.Data)
+	}
+
+	req = httptest.NewRequest("GET", "/api/statistics?exercise=Squat&aggregate=day", nil)
+	w = httptest.NewRecorder()
+	getStatisticsData(w, req)
+	resp = StatisticsResponse{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Data) != 1 || resp.Data[0].Sessions != 2 || resp.Data[0].TotalVolume != 1100 {
+		t.Errorf("expected one merged day with 2 sessions, got %+v", resp.Data)
+	}
+}
+
+func TestStatisticsAPI_WeekAndMonthAggregation(t *testing.T) {
+	setupTestDB(t)
+	for _, date := range []string{"2026-03-03", "2026-03-05", "2026-03-12", "2026-04-01"} {
+		seedWorkout(t, date, "custom", 0, []Exercise{
+			{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+		})
+	}
+
+	tests := []struct {
+		level string
+		dates []string
+	}{
+		{"week", []string{"2026-03-02", "2026-03-09", "2026-03-30"}},
+		{"month", []string{"2026-03-01", "2026-04-01"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/statistics?exercise=Squat&aggregate="+tt.level, nil)
+			w := httptest.NewRecorder()
+			getStatisticsData(w, req)
+
+			var resp StatisticsResponse
+			json.NewDecoder(w.Body).Decode(&resp)
+			if len(resp.Data) != len(tt.dates) {
+				t.Fatalf("expected %d points, got %+v", len(tt.dates), resp.Data)
+			}
+			for i, date := range tt.dates {
+				if resp.Data[i].Date != date {
+					t.Errorf("point %d: expected %s, got %s", i, date, resp.Data[i].Date)
+				}
+			}
+		})
+	}
+}
+
+func TestStatisticsAPI_DateRangeAndInvalidLevel(t *testing.T) {
+	setupTestDB(t)
+	for _, date := range []string{"2026-01-10", "2026-02-10", "2026-03-10"} {
+		seedWorkout(t, date, "custom", 0, []Exercise{
+			{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+		})
+	}
+
+	req := httptest.NewRequest("GET", "/api/statistics?exercise=Squat&from=2026-02-01&to=2026-02-28", nil)
+	w := httptest.NewRecorder()
+	getStatisticsData(w, req)
+	var resp StatisticsResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Data) != 1 || resp.Data[0].Date != "2026-02-10" {
+		t.Errorf("expected only 2026-02-10, got %+v", resp.Data)
+	}
+
+	req = httptest.NewRequest("GET", "/api/statistics?exercise=Squat&aggregate=fortnight", nil)
+	w = httptest.NewRecorder()
+	getStatisticsData(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown level, got %d", w.Code)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Training calendar
+// ---------------------------------------------------------------------------
+
+func TestBuildCalendar(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-02", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+	})
+	seedWorkout(t, "2026-03-02", "gzclp", 1, []Exercise{
+		{Name: "Bench Press", Sets: []Set{{Weight: 60, Reps: 5}}},
+	})
+	seedWorkout(t, "2025-12-30", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+	})
+
+	today := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	calendar, err := buildCalendar("2026-01-01", "2026-12-31", today)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calendar.Days) != 1 {
+		t.Fatalf("expected 1 training day in range, got %+v", calendar.Days)
+	}
+	day := calendar.Days[0]
+	if day.Workouts != 2 || day.Tonnage != 800 || len(day.WorkoutTypes) != 2 {
+		t.Errorf("unexpected day totals: %+v", day)
+	}
+}
+
+func TestWeeklyStreaks(t *testing.T) {
+	weeks := map[string]bool{
+		"2026-01-05": true,
+		"2026-01-12": true,
+		"2026-01-19": true,
+		"2026-02-09": true,
+		"2026-02-16": true,
+	}
+
+	// Week of 2026-02-23 hasn't been trained yet, so the streak is still alive
+	current, longest := weeklyStreaks(weeks, time.Date(2026, 2, 25, 0, 0, 0, 0, time.UTC))
+	if current != 2 || longest != 3 {
+		t.Errorf("expected current 2, longest 3; got %d, %d", current, longest)
+	}
+
+	// A full missed week ends the current streak
+	current, _ = weeklyStreaks(weeks, time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC))
+	if current != 0 {
+		t.Errorf("expected broken streak, got %d", current)
+	}
+}
+
+func TestCalendarAPI_InvalidYear(t *testing.T) {
+	setupTestDB(t)
+
+	req := httptest.NewRequest("GET", "/api/calendar?year=abc", nil)
+	w := httptest.NewRecorder()
+	getCalendarData(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/calendar?year=2026", nil)
+	w = httptest.NewRecorder()
+	getCalendarData(w, req)
+	var calendar CalendarResponse
+	json.NewDecoder(w.Body).Decode(&calendar)
+	if calendar.From != "2026-01-01" || calendar.To != "2026-12-31" {
+		t.Errorf("unexpected range: %s to %s", calendar.From, calendar.To)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Dashboard
+// ---------------------------------------------------------------------------
+
+func TestBuildDashboard(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultGZCLPDayExercises()
+	seedWorkout(t, "2026-03-02", "gzclp", 1, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+	})
+	seedWorkout(t, "2026-03-04", "gzclp", 2, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 110, Reps: 5}}},
+	})
+	seedWorkout(t, "2026-02-20", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 90, Reps: 5}}},
+	})
+
+	dashboard, err := buildDashboard(time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dashboard.NextGZCLPDay != 1 || len(dashboard.NextGZCLPExercises) != 5 {
+		t.Errorf("unexpected next GZCLP day: %d %+v", dashboard.NextGZCLPDay, dashboard.NextGZCLPExercises)
+	}
+	if dashboard.LastWorkoutDate != "2026-03-04" || dashboard.DaysSinceLastWorkout != 2 {
+		t.Errorf("expected last workout 2 days ago, got %s (%d)", dashboard.LastWorkoutDate, dashboard.DaysSinceLastWorkout)
+	}
+	if dashboard.WeekSessions != 2 || dashboard.WeekTonnage != 1050 {
+		t.Errorf("expected 2 sessions and 1050 kg this week, got %d and %.0f", dashboard.WeekSessions, dashboard.WeekTonnage)
+	}
+	if len(dashboard.RecentRecords) == 0 {
+		t.Error("expected recent PRs")
+	}
+
+	var squat *LiftSparkline
+	for i := range dashboard.MainLifts {
+		if dashboard.MainLifts[i].Name == "Squat" {
+			squat = &dashboard.MainLifts[i]
+		}
+	}
+	if squat == nil || squat.Points == "" || squat.Latest != 123.75 {
+		t.Errorf("expected Squat sparkline ending at 123.75, got %+v", squat)
+	}
+}
+
+func TestBuildDashboard_MainLiftsInRotationOrder(t *testing.T) {
+	setupTestDB(t)
+	w := putGZCLPConfig(t, `{"days": 3, "slots": [
+		{"day": 1, "slot": "T1", "exercise_name": "Squat"},
+		{"day": 2, "slot": "T1", "exercise_name": "Bench Press"},
+		{"day": 3, "slot": "T1", "exercise_name": "Squat"}
+	]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, name := range []string{"Bench Press", "Squat"} {
+		seedWorkout(t, "2026-03-02", "custom", 0, []Exercise{{Name: name, Sets: []Set{{Weight: 100, Reps: 5}}}})
+		seedWorkout(t, "2026-03-04", "custom", 0, []Exercise{{Name: name, Sets: []Set{{Weight: 105, Reps: 5}}}})
+	}
+
+	dashboard, err := buildDashboard(time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dashboard.MainLifts) != 2 || dashboard.MainLifts[0].Name != "Squat" || dashboard.MainLifts[1].Name != "Bench Press" {
+		t.Errorf("expected Squat then Bench Press, got %+v", dashboard.MainLifts)
+	}
+}
+
+func TestSparklinePoints(t *testing.T) {
+	if got := sparklinePoints([]float64{100}, 100, 24); got != "" {
+		t.Errorf("expected no line for a single value, got %q", got)
+	}
+	if got := sparklinePoints([]float64{100, 110, 105}, 100, 20); got != "0.0,20.0 50.0,0.0 100.0,10.0" {
+		t.Errorf("unexpected points: %q", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Deloads
+// ---------------------------------------------------------------------------
+
+func TestDeloadWeight(t *testing.T) {
+	if got := deloadWeight(100, 10, 2.5); got != 90 {
+		t.Errorf("expected 90, got %.1f", got)
+	}
+	if got := deloadWeight(72.5, 10, 2.5); got != 65 {
+		t.Errorf("expected 65 (65.25 rounded to 2.5), got %.1f", got)
+	}
+}
+
+func TestGZCLPSessionFailed(t *testing.T) {
+	tests := []struct {
+		stage string
+		reps  []int
+		want  bool
+	}{
+		{"5x3", []int{3, 3, 3, 3, 5}, false},
+		{"5x3", []int{3, 3, 3, 2, 1}, true},
+		{"5x3", []int{3, 3, 3, 3}, true},
+		{"6x2", []int{2, 2, 2, 2, 2, 4}, false},
+		{"10x1", []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, false},
+		{"3x10", []int{10, 10, 10}, false},
+		{"3x10", []int{10, 10, 8}, true},
+		{"3x8", []int{8, 8, 8}, false},
+		{"3x6", []int{6, 6, 6}, false},
+		{"", []int{1}, false},
+	}
+	for _, tt := range tests {
+		if got := gzclpSessionFailed(tt.stage, tt.reps); got != tt.want {
+			t.Errorf("gzclpSessionFailed(%s, %v) = %v, want %v", tt.stage, tt.reps, got, tt.want)
+		}
+	}
+}
+
+func TestGetDeloadStatus_CompletedLaterStageIsNotAFailure(t *testing.T) {
+	setupTestDB(t)
+	setSetting("deload_failure_count", "1")
+	setSetting("deload_failure_lifts", "1")
+	seedWorkout(t, "2026-03-02", "gzclp", 1, []Exercise{
+		{Name: "Bench Press", Tier: "T2", Stage: "3x8", Sets: []Set{{Weight: 60, Reps: 8}, {Weight: 60, Reps: 8}, {Weight: 60, Reps: 8}}},
+	})
+
+	status, err := getDeloadStatus(time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.Due || len(status.FailingLifts) != 0 {
+		t.Errorf("expected a completed 3x8 not to count as a failure, got %+v", status)
+	}
+}
+
+func TestGetDeloadStatus_EveryNWeeks(t *testing.T) {
+	setupTestDB(t)
+	setSetting("deload_every_weeks", "4")
+	seedWorkout(t, "2026-01-05", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+	})
+
+	status, _ := getDeloadStatus(time.Date(2026, 1, 26, 0, 0, 0, 0, time.UTC))
+	if status.Due {
+		t.Errorf("expected no deload after 3 weeks, got %+v", status)
+	}
+	status, _ = getDeloadStatus(time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC))
+	if !status.Due || status.WeeksSinceDeload != 4 {
+		t.Errorf("expected deload due after 4 weeks, got %+v", status)
+	}
+
+	// Logging a deload restarts the count
+	saveWorkoutToDB(Workout{Date: "2026-02-02", WorkoutType: "custom", IsDeload: true})
+	status, _ = getDeloadStatus(time.Date(2026, 2, 9, 0, 0, 0, 0, time.UTC))
+	if status.Due || status.LastDeload != "2026-02-02" {
+		t.Errorf("expected deload count reset, got %+v", status)
+	}
+}
+
+func TestGetDeloadStatus_RepeatedFailures(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultGZCLPDayExercises()
+
+	// Day 1 is Squat T1 / Bench Press T2 in the default rotation
+	failed := []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 3}, {Weight: 100, Reps: 3}, {Weight: 100, Reps: 2}, {Weight: 100, Reps: 2}, {Weight: 100, Reps: 1}}},
+		{Name: "Bench Press", Sets: []Set{{Weight: 50, Reps: 10}, {Weight: 50, Reps: 8}, {Weight: 50, Reps: 7}}},
+	}
+	seedWorkout(t, "2026-03-02", "gzclp", 1, failed)
+	status, _ := getDeloadStatus(time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC))
+	if status.Due {
+		t.Errorf("expected one failure to be tolerated, got %+v", status)
+	}
+
+	seedWorkout(t, "2026-03-09", "gzclp", 1, failed)
+	status, _ = getDeloadStatus(time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC))
+	if !status.Due || len(status.FailingLifts) != 2 {
+		t.Errorf("expected deload due with 2 failing lifts, got %+v", status)
+	}
+}
+
+func TestCreateWorkout_StoresDeloadFlag(t *testing.T) {
+	setupTestDB(t)
+
+	form := url.Values{}
+	form.Set("date", "2026-03-15")
+	form.Set("workout_type", "gzclp")
+	form.Set("workout_day", "1")
+	form.Set("is_deload", "true")
+	form.Set("exercise_0", "Squat")
+	form.Set("reps_0_0", "3")
+	form.Set("weight_0_0", "90")
+
+	req := httptest.NewRequest("POST", "/workout/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	createWorkout(w, req)
+
+	workouts, _ := getWorkoutsFromDB()
+	if len(workouts) != 1 || !workouts[0].IsDeload {
+		t.Errorf("expected a deload workout, got %+v", workouts)
+	}
+}
+
+func TestStatisticsAPI_DeloadFilter(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-02", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+	})
+	saveWorkoutToDB(Workout{Date: "2026-03-09", WorkoutType: "custom", IsDeload: true, Exercises: []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 90, Reps: 5}}},
+	}})
+
+	tests := []struct {
+		param  string
+		points int
+	}{
+		{"", 2},
+		{"exclude", 1},
+		{"only", 1},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/statistics?exercise=Squat&deload="+tt.param, nil)
+		w := httptest.NewRecorder()
+		getStatisticsData(w, req)
+		var resp StatisticsResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		if len(resp.Data) != tt.points {
+			t.Errorf("deload=%q: expected %d points, got %d", tt.param, tt.points, len(resp.Data))
+		}
+		if tt.param == "only" && len(resp.Data) == 1 && !resp.Data[0].Deload {
+			t.Error("expected deload point to be flagged")
+		}
+	}
+}
+
+func TestLatestExerciseAPI_DeloadWeights(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-02", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+	})
+
+	req := httptest.NewRequest("GET", "/api/latest-exercise?name=Squat&deload=true", nil)
+	w := httptest.NewRecorder()
+	getLatestExercise(w, req)
+
+	var resp struct {
+		Sets []struct {
+			Weight       float64 `json:"weight"`
+			DeloadWeight float64 `json:"deload_weight"`
+		} `json:"sets"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Sets) != 1 || resp.Sets[0].DeloadWeight != 90 {
+		t.Errorf("expected deload weight 90, got %+v", resp.Sets)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Workout templates
+// ---------------------------------------------------------------------------
+
+func TestTemplatesAPI_CRUD(t *testing.T) {
+	setupTestDB(t)
+
+	body := `{"name": "Arms", "exercises": [
+		{"exercise_name": "Bicep Curl", "sets": 3, "reps": 12, "weight": 15},
+		{"exercise_name": "Tricep Pushdown", "sets": 3, "reps": 15, "weight": 25}
+	]}`
+	req := httptest.NewRequest("POST", "/api/templates", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handleTemplatesAPI(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create failed: %d %s", w.Code, w.Body.String())
+	}
+	var created WorkoutTemplate
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.ID == 0 {
+		t.Fatal("expected template ID")
+	}
+
+	// Update: rename and drop an exercise
+	body = fmt.Sprintf(`{"id": %d, "name": "Arms B", "exercises": [{"exercise_name": "Hammer Curl", "sets": 4, "reps": 10, "weight": 12.5}]}`, created.ID)
+	req = httptest.NewRequest("PUT", "/api/templates", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	handleTemplatesAPI(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update failed: %d %s", w.Code, w.Body.String())
+	}
+
+	got, err := getWorkoutTemplate(created.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != "Arms B" || len(got.Exercises) != 1 || got.Exercises[0].ExerciseName != "Hammer Curl" {
+		t.Errorf("unexpected template after update: %+v", got)
+	}
+
+	req = httptest.NewRequest("DELETE", fmt.Sprintf("/api/templates?id=%d", created.ID), nil)
+	w = httptest.NewRecorder()
+	handleTemplatesAPI(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete failed: %d", w.Code)
+	}
+	var remaining int
+	db.QueryRow("SELECT COUNT(*) FROM template_exercises").Scan(&remaining)
+	if templates, _ := getWorkoutTemplates(); len(templates) != 0 || remaining != 0 {
+		t.Errorf("expected template and its exercises deleted, got %+v (%d rows)", templates, remaining)
+	}
+}
+
+func TestTemplatesAPI_Validation(t *testing.T) {
+	setupTestDB(t)
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"name": "", "exercises": [{"exercise_name": "Squat", "sets": 3, "reps": 5}]}`, http.StatusBadRequest},
+		{`{"name": "Empty", "exercises": []}`, http.StatusBadRequest},
+		{`{"name": "Bad sets", "exercises": [{"exercise_name": "Squat", "sets": 0, "reps": 5}]}`, http.StatusBadRequest},
+		{`{"name": "Legs", "exercises": [{"exercise_name": "Squat", "sets": 3, "reps": 5}]}`, http.StatusOK},
+		{`{"name": "Legs", "exercises": [{"exercise_name": "Lunge", "sets": 3, "reps": 8}]}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/api/templates", strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		handleTemplatesAPI(w, req)
+		if w.Code != tt.code {
+			t.Errorf("POST %s: expected %d, got %d", tt.body, tt.code, w.Code)
+		}
+	}
+}
+
+func TestNewWorkoutForm_FromTemplate(t *testing.T) {
+	setupTestDB(t)
+	req := httptest.NewRequest("POST", "/api/templates", strings.NewReader(
+		`{"name": "Conditioning", "exercises": [{"exercise_name": "Kettlebell Swing", "sets": 5, "reps": 20, "weight": 24}]}`))
+	w := httptest.NewRecorder()
+	handleTemplatesAPI(w, req)
+	var created WorkoutTemplate
+	json.NewDecoder(w.Body).Decode(&created)
+
+	if sets := created.toExercises(nil, nil)[0].Sets; len(sets) != 5 || sets[0].Reps != 20 || sets[0].Weight != 24 {
+		t.Errorf("expected 5 prefilled sets of 20 @ 24, got %+v", sets)
+	}
+
+	req = httptest.NewRequest("GET", fmt.Sprintf("/workout/new?template=%d", created.ID), nil)
+	w = httptest.NewRecorder()
+	newWorkoutForm(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"name":"Kettlebell Swing"`) {
+		t.Error("expected form to be prefilled from the template")
+	}
+}
+
+func TestTemplatesPageHandler(t *testing.T) {
+	setupTestDB(t)
+
+	req := httptest.NewRequest("GET", "/templates", nil)
+	w := httptest.NewRecorder()
+	templatesPage(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Repeat workout
+// ---------------------------------------------------------------------------
+
+func TestGetWorkoutByID(t *testing.T) {
+	setupTestDB(t)
+	id := seedWorkout(t, "2026-03-02", "custom", 0, []Exercise{
+		{Name: "Bicep Curl", Sets: []Set{{Weight: 15, Reps: 12}, {Weight: 15, Reps: 10}}},
+		{Name: "Dip", Sets: []Set{{Weight: 0, Reps: 10}}},
+	})
+
+	workout, err := getWorkoutByID(id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(workout.Exercises) != 2 || workout.Exercises[0].Name != "Bicep Curl" || len(workout.Exercises[0].Sets) != 2 {
+		t.Errorf("unexpected workout: %+v", workout)
+	}
+
+	if _, err := getWorkoutByID(9999); err != sql.ErrNoRows {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestRepeatExercises_Increment(t *testing.T) {
+	workout := Workout{Exercises: []Exercise{
+		{Name: "Bicep Curl", Sets: []Set{{Weight: 15, Reps: 12}}},
+		{Name: "Dip", Sets: []Set{{Weight: 0, Reps: 10}}},
+	}}
+
+	repeated := repeatExercises(workout, map[string]float64{"Bicep Curl": 2.5, "Dip": 2.5})
+	if repeated[0].Sets[0].Weight != 17.5 || repeated[0].Sets[0].Reps != 12 {
+		t.Errorf("expected 17.5 kg x 12, got %+v", repeated[0].Sets[0])
+	}
+	if repeated[1].Sets[0].Weight != 0 {
+		t.Errorf("expected bodyweight set to stay at 0, got %+v", repeated[1].Sets[0])
+	}
+	if workout.Exercises[0].Sets[0].Weight != 15 {
+		t.Error("expected original workout to be left untouched")
+	}
+}
+
+func TestNewWorkoutForm_Repeat(t *testing.T) {
+	setupTestDB(t)
+	id := seedWorkout(t, "2026-03-02", "custom", 0, []Exercise{
+		{Name: "Bicep Curl", Sets: []Set{{Weight: 15, Reps: 12}}},
+	})
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/workout/new?repeat=%d&increment=2.5", id), nil)
+	w := httptest.NewRecorder()
+	newWorkoutForm(w, req)
+
+	if !strings.Contains(w.Body.String(), `"weight":17.5`) {
+		t.Error("expected form to be prefilled with the incremented workout")
+	}
+}
+
+// ---- Drafts ----
+
+func TestDraftsAPI_SaveLoadDelete(t *testing.T) {
+	setupTestDB(t)
+
+	body := `{"date":"2026-03-04","workout_type":"custom","exercises":[{"name":"Bench Press","sets":[{"reps":5,"weight":80}]}]}`
+	req := httptest.NewRequest("PUT", "/api/drafts?form=workout", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handleDraftsAPI(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/drafts?form=workout", nil)
+	w = httptest.NewRecorder()
+	handleDraftsAPI(w, req)
+	var draft Draft
+	if err := json.NewDecoder(w.Body).Decode(&draft); err != nil {
+		t.Fatalf("failed to decode draft: %v", err)
+	}
+	if draft.Workout.Date != "2026-03-04" || len(draft.Workout.Exercises) != 1 || draft.UpdatedAt == "" {
+		t.Errorf("unexpected draft: %+v", draft)
+	}
+
+	req = httptest.NewRequest("DELETE", "/api/drafts?form=workout", nil)
+	w = httptest.NewRecorder()
+	handleDraftsAPI(w, req)
+
+	req = httptest.NewRequest("GET", "/api/drafts?form=workout", nil)
+	w = httptest.NewRecorder()
+	handleDraftsAPI(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", w.Code)
+	}
+}
+
+func TestDraftsAPI_InvalidForm(t *testing.T) {
+	setupTestDB(t)
+
+	req := httptest.NewRequest("GET", "/api/drafts?form=other", nil)
+	w := httptest.NewRecorder()
+	handleDraftsAPI(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestFinishDraft_SavesCompletedSets(t *testing.T) {
+	setupTestDB(t)
+	saveDraft("gzclp", Workout{
+		Date:        "2026-03-04",
+		WorkoutType: "gzclp",
+		WorkoutDay:  1,
+		Exercises: []Exercise{
+			{Name: "Squat", Sets: []Set{{Reps: 3, Weight: 100}, {Reps: 0, Weight: 100}, {Reps: 3, Empty: true}}},
+			{Name: "Bench Press", Sets: []Set{{Reps: 0, Weight: 60, Empty: true}}},
+		},
+	})
+
+	req := httptest.NewRequest("POST", "/api/drafts/finish?form=gzclp", nil)
+	w := httptest.NewRecorder()
+	finishDraft(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	workouts, _ := getWorkoutsFromDB()
+	if len(workouts) != 1 || len(workouts[0].Exercises) != 1 || len(workouts[0].Exercises[0].Sets) != 2 {
+		t.Fatalf("expected one exercise with two completed sets, got %+v", workouts)
+	}
+	// Like the form, a set with both values entered is kept even at 0 reps
+	if sets := workouts[0].Exercises[0].Sets; sets[1].Reps != 0 || sets[1].Weight != 100 {
+		t.Errorf("expected the failed 0-rep set to be kept, got %+v", sets)
+	}
+	if _, err := getDraft("gzclp"); err != sql.ErrNoRows {
+		t.Errorf("expected draft to be deleted, got %v", err)
+	}
+	var currentDay int
+	db.QueryRow("SELECT current_day FROM gzclp_settings WHERE id = 1").Scan(&currentDay)
+	if currentDay != 2 {
+		t.Errorf("expected GZCLP to advance to day 2, got %d", currentDay)
+	}
+}
+
+func TestFinishDraft_ValidatesTierAndStage(t *testing.T) {
+	setupTestDB(t)
+	for _, exercise := range []Exercise{
+		{Name: "Squat", Tier: "T9", Sets: []Set{{Reps: 3, Weight: 100}}},
+		{Name: "Squat", Tier: "T1", Stage: "heavy", Sets: []Set{{Reps: 3, Weight: 100}}},
+	} {
+		saveDraft("gzclp", Workout{Date: "2026-03-04", WorkoutType: "gzclp", WorkoutDay: 1, Exercises: []Exercise{exercise}})
+
+		w := httptest.NewRecorder()
+		finishDraft(w, httptest.NewRequest("POST", "/api/drafts/finish?form=gzclp", nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for tier %q stage %q, got %d", exercise.Tier, exercise.Stage, w.Code)
+		}
+	}
+	if workouts, _ := getWorkoutsFromDB(); len(workouts) != 0 {
+		t.Errorf("expected nothing to be saved, got %+v", workouts)
+	}
+}
+
+func TestFinishDraft_NoDraft(t *testing.T) {
+	setupTestDB(t)
+
+	req := httptest.NewRequest("POST", "/api/drafts/finish?form=workout", nil)
+	w := httptest.NewRecorder()
+	finishDraft(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestCreateWorkout_ClearsDraft(t *testing.T) {
+	setupTestDB(t)
+	saveDraft("workout", Workout{Date: "2026-03-04"})
+
+	form := url.Values{}
+	form.Set("date", "2026-03-04")
+	form.Set("workout_type", "custom")
+	form.Set("draft_form", "workout")
+	form.Set("exercise_0", "Bench Press")
+	form.Set("weight_0_0", "80")
+	form.Set("reps_0_0", "5")
+	req := httptest.NewRequest("POST", "/workout/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	createWorkout(w, req)
+
+	if _, err := getDraft("workout"); err != sql.ErrNoRows {
+		t.Errorf("expected draft to be deleted, got %v", err)
+	}
+}
+
+// ---- Offline sync ----
+
+func postSync(t *testing.T, body string) SyncResponse {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/api/sync", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	syncWorkouts(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response SyncResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode sync response: %v", err)
+	}
+	return response
+}
+
+func TestSyncWorkouts_CreatesAndDeduplicates(t *testing.T) {
+	setupTestDB(t)
+	body := `{"workouts":[{"client_id":"abc-1","workout":{"date":"2026-03-04","workout_type":"gzclp","workout_day":1,"exercises":[{"name":"Squat","sets":[{"reps":3,"weight":100}]}]}}]}`
+
+	first := postSync(t, body)
+	if first.Results[0].Status != "created" || first.Results[0].WorkoutID == 0 {
+		t.Fatalf("expected created, got %+v", first.Results[0])
+	}
+
+	second := postSync(t, body)
+	if second.Results[0].Status != "duplicate" || second.Results[0].WorkoutID != first.Results[0].WorkoutID {
+		t.Errorf("expected duplicate of workout %d, got %+v", first.Results[0].WorkoutID, second.Results[0])
+	}
+
+	workouts, _ := getWorkoutsFromDB()
+	if len(workouts) != 1 {
+		t.Errorf("expected 1 workout, got %d", len(workouts))
+	}
+	day, _ := getNextGZCLPWorkoutDay()
+	if day != 2 {
+		t.Errorf("expected rotation to advance once to day 2, got %d", day)
+	}
+}
+
+func TestSyncWorkouts_GZCLPConflict(t *testing.T) {
+	setupTestDB(t)
+	db.Exec("UPDATE gzclp_settings SET current_day = 3 WHERE id = 1")
+	item := `{"client_id":"abc-2","force":%t,"workout":{"date":"2026-03-04","workout_type":"gzclp","workout_day":1,"exercises":[{"name":"Squat","sets":[{"reps":3,"weight":100}]}]}}`
+
+	conflict := postSync(t, `{"workouts":[`+fmt.Sprintf(item, false)+`]}`)
+	if conflict.Results[0].Status != "conflict" || conflict.Results[0].CurrentDay != 3 {
+		t.Fatalf("expected conflict on day 3, got %+v", conflict.Results[0])
+	}
+	if workouts, _ := getWorkoutsFromDB(); len(workouts) != 0 {
+		t.Errorf("expected conflicting workout not to be saved, got %d", len(workouts))
+	}
+
+	forced := postSync(t, `{"workouts":[`+fmt.Sprintf(item, true)+`]}`)
+	if forced.Results[0].Status != "created" {
+		t.Fatalf("expected forced workout to be created, got %+v", forced.Results[0])
+	}
+	day, _ := getNextGZCLPWorkoutDay()
+	if day != 2 {
+		t.Errorf("expected forced workout to continue the rotation from day 1, got %d", day)
+	}
+	events, _ := getGZCLPEvents(db)
+	if len(events) == 0 || events[len(events)-1].Type != "workout" || events[len(events)-1].WorkoutID != forced.Results[0].WorkoutID {
+		t.Errorf("expected forced workout to be logged as a workout event, got %+v", events)
+	}
+}
+
+func TestSyncWorkouts_ReportsSavedItemsOnError(t *testing.T) {
+	setupTestDB(t)
+	db.Exec(`CREATE TRIGGER fail_insert BEFORE INSERT ON workouts WHEN NEW.date = '2026-03-05'
+		BEGIN SELECT RAISE(ABORT, 'disk full'); END`)
+	item := `{"client_id":"abc-%d","workout":{"date":"2026-03-0%d","exercises":[{"name":"Squat","sets":[{"reps":3,"weight":100}]}]}}`
+
+	response := postSync(t, `{"workouts":[`+fmt.Sprintf(item, 1, 4)+`,`+fmt.Sprintf(item, 2, 5)+`,`+fmt.Sprintf(item, 3, 6)+`]}`)
+	if len(response.Results) != 1 || response.Results[0].ClientID != "abc-1" || response.Results[0].Status != "created" {
+		t.Fatalf("expected only the item saved before the error to be reported, got %+v", response.Results)
+	}
+	if workouts, _ := getWorkoutsFromDB(); len(workouts) != 1 {
+		t.Errorf("expected the items after the error to stay unsynced, got %d workouts", len(workouts))
+	}
+}
+
+func TestSyncWorkouts_Invalid(t *testing.T) {
+	setupTestDB(t)
+	response := postSync(t, `{"workouts":[
+		{"client_id":"","workout":{"date":"2026-03-04","exercises":[{"name":"Squat","sets":[{"reps":3,"weight":100}]}]}},
+		{"client_id":"abc-3","workout":{"date":"bad","exercises":[{"name":"Squat","sets":[{"reps":3,"weight":100}]}]}},
+		{"client_id":"abc-4","workout":{"date":"2026-03-04","exercises":[{"name":"Squat","sets":[{"reps":3,"weight":0,"empty":true}]}]}}
+	]}`)
+
+	for i, result := range response.Results {
+		if result.Status != "invalid" {
+			t.Errorf("item %d: expected invalid, got %+v", i, result)
+		}
+	}
+}
+
+// ---- Idempotent submission ----
+
+func submitGZCLPForm(t *testing.T, key string) SaveWorkoutResult {
+	t.Helper()
+	form := url.Values{}
+	form.Set("date", "2026-03-04")
+	form.Set("workout_type", "gzclp")
+	form.Set("workout_day", "1")
+	form.Set("idempotency_key", key)
+	form.Set("exercise_0", "Squat")
+	form.Set("weight_0_0", "100")
+	form.Set("reps_0_0", "3")
+	req := httptest.NewRequest("POST", "/workout/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	createWorkout(w, req)
+
+	var result SaveWorkoutResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	return result
+}
+
+func TestCreateWorkout_IdempotencyKey(t *testing.T) {
+	setupTestDB(t)
+
+	first := submitGZCLPForm(t, "token-1")
+	second := submitGZCLPForm(t, "token-1")
+
+	if first.Duplicate || !second.Duplicate {
+		t.Errorf("expected only the second submission to be a duplicate, got %+v / %+v", first, second)
+	}
+	if second.WorkoutID != first.WorkoutID {
+		t.Errorf("expected original workout %d, got %d", first.WorkoutID, second.WorkoutID)
+	}
+	if len(second.Records) != len(first.Records) {
+		t.Errorf("expected original records to be returned, got %d vs %d", len(second.Records), len(first.Records))
+	}
+
+	workouts, _ := getWorkoutsFromDB()
+	if len(workouts) != 1 {
+		t.Errorf("expected 1 workout, got %d", len(workouts))
+	}
+	day, _ := getNextGZCLPWorkoutDay()
+	if day != 2 {
+		t.Errorf("expected rotation to advance once to day 2, got %d", day)
+	}
+}
+
+func TestCreateWorkout_DistinctKeys(t *testing.T) {
+	setupTestDB(t)
+
+	submitGZCLPForm(t, "token-1")
+	db.Exec("UPDATE gzclp_settings SET current_day = 1 WHERE id = 1")
+	submitGZCLPForm(t, "token-2")
+
+	workouts, _ := getWorkoutsFromDB()
+	if len(workouts) != 2 {
+		t.Errorf("expected 2 workouts, got %d", len(workouts))
+	}
+}
+
+func TestNewFormToken_Unique(t *testing.T) {
+	a, b := newFormToken(), newFormToken()
+	if len(a) != 32 || a == b {
+		t.Errorf("expected two distinct 32 character tokens, got %q and %q", a, b)
+	}
+}
+
+// ---- GZCLP day validation ----
+
+func staleGZCLPForm(override bool) url.Values {
+	form := url.Values{}
+	form.Set("date", "2026-03-04")
+	form.Set("workout_type", "gzclp")
+	form.Set("workout_day", "1")
+	form.Set("exercise_0", "Squat")
+	form.Set("weight_0_0", "100")
+	form.Set("reps_0_0", "3")
+	if override {
+		form.Set("gzclp_override", "true")
+	}
+	return form
+}
+
+func TestCreateWorkout_GZCLPDayConflict(t *testing.T) {
+	setupTestDB(t)
+	db.Exec("UPDATE gzclp_settings SET current_day = 3 WHERE id = 1")
+
+	req := httptest.NewRequest("POST", "/workout/create", strings.NewReader(staleGZCLPForm(false).Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	createWorkout(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", w.Code)
+	}
+	var conflict GZCLPDayConflict
+	json.NewDecoder(w.Body).Decode(&conflict)
+	if conflict.SubmittedDay != 1 || conflict.CurrentDay != 3 {
+		t.Errorf("unexpected conflict: %+v", conflict)
+	}
+	if workouts, _ := getWorkoutsFromDB(); len(workouts) != 0 {
+		t.Errorf("expected nothing to be saved, got %d workouts", len(workouts))
+	}
+	if day, _ := getNextGZCLPWorkoutDay(); day != 3 {
+		t.Errorf("expected rotation to stay on day 3, got %d", day)
+	}
+}
+
+func TestCreateWorkout_GZCLPConflictPage(t *testing.T) {
+	setupTestDB(t)
+	db.Exec("UPDATE gzclp_settings SET current_day = 3 WHERE id = 1")
+
+	req := httptest.NewRequest("POST", "/workout/create", strings.NewReader(staleGZCLPForm(false).Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	createWorkout(w, req)
+
+	body := w.Body.String()
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", w.Code)
+	}
+	if !strings.Contains(body, `name="gzclp_override" value="true"`) || !strings.Contains(body, `name="exercise_0" value="Squat"`) {
+		t.Error("expected conflict page to resubmit the workout as an override")
+	}
+}
+
+func TestCreateWorkout_GZCLPOverride(t *testing.T) {
+	setupTestDB(t)
+	db.Exec("UPDATE gzclp_settings SET current_day = 3 WHERE id = 1")
+
+	req := httptest.NewRequest("POST", "/workout/create", strings.NewReader(staleGZCLPForm(true).Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	createWorkout(w, req)
+
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d", w.Code)
+	}
+	var expectedDay int
+	db.QueryRow("SELECT expected_day FROM workouts").Scan(&expectedDay)
+	if expectedDay != 3 {
+		t.Errorf("expected override of day 3 to be recorded, got %d", expectedDay)
+	}
+	if day, _ := getNextGZCLPWorkoutDay(); day != 2 {
+		t.Errorf("expected rotation to continue from the logged day to day 2, got %d", day)
+	}
+}
+
+// ---- GZCLP rotation on delete ----
+
+func deleteWorkoutByID(t *testing.T, id int) DeleteWorkoutResult {
+	t.Helper()
+	form := url.Values{}
+	form.Set("id", fmt.Sprintf("%d", id))
+	req := httptest.NewRequest("POST", "/workout/delete", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	deleteWorkout(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete failed: %d", w.Code)
+	}
+	var result DeleteWorkoutResult
+	json.NewDecoder(w.Body).Decode(&result)
+	return result
+}
+
+func recordGZCLPDay(t *testing.T, day int, override bool) int {
+	t.Helper()
+	result, err := recordWorkout(Workout{Date: "2026-03-04", WorkoutType: "gzclp", WorkoutDay: day, Exercises: []Exercise{
+		{Name: "Squat", Sets: []Set{{Reps: 3, Weight: 100}}},
+	}}, override)
+	if err != nil {
+		t.Fatalf("failed to record day %d: %v", day, err)
+	}
+	return result.WorkoutID
+}
+
+func TestDeleteWorkout_RewindsLatestGZCLPDay(t *testing.T) {
+	setupTestDB(t)
+	recordGZCLPDay(t, 1, false)
+	latest := recordGZCLPDay(t, 2, false)
+
+	result := deleteWorkoutByID(t, latest)
+	if !result.GZCLPReverted || result.GZCLPDay != 2 {
+		t.Errorf("expected rotation to move back to day 2, got %+v", result)
+	}
+	if day, _ := getNextGZCLPWorkoutDay(); day != 2 {
+		t.Errorf("expected current day 2, got %d", day)
+	}
+}
+
+func TestDeleteWorkout_OlderGZCLPDayLeavesRotation(t *testing.T) {
+	setupTestDB(t)
+	older := recordGZCLPDay(t, 1, false)
+	recordGZCLPDay(t, 2, false)
+
+	result := deleteWorkoutByID(t, older)
+	if result.GZCLPReverted || result.GZCLPDay != 3 {
+		t.Errorf("expected rotation to stay on day 3, got %+v", result)
+	}
+}
+
+func TestDeleteWorkout_GZCLPAfterSkipLeavesRotation(t *testing.T) {
+	setupTestDB(t)
+	id := recordGZCLPDay(t, 1, false)
+	postSkip(t)
+
+	if result := deleteWorkoutByID(t, id); result.GZCLPReverted {
+		t.Errorf("expected a later skip to keep the rotation, got %+v", result)
+	}
+}
+
+func TestDeleteWorkout_RewindsGZCLPOverride(t *testing.T) {
+	setupTestDB(t)
+	id := recordGZCLPDay(t, 3, true)
+
+	result := deleteWorkoutByID(t, id)
+	if !result.GZCLPReverted || result.GZCLPDay != 1 {
+		t.Errorf("expected rotation to return to day 1 from before the override, got %+v", result)
+	}
+}
+
+func TestDeleteWorkout_RewindsGZCLPSwap(t *testing.T) {
+	setupTestDB(t)
+	postDayChange(t, `{"action":"swap"}`)
+	id := recordGZCLPDay(t, 2, false)
+
+	result := deleteWorkoutByID(t, id)
+	if !result.GZCLPReverted || result.GZCLPDay != 2 {
+		t.Fatalf("expected rotation to return to the swapped day 2, got %+v", result)
+	}
+	state, _ := loadGZCLPState(db)
+	if fmt.Sprint(state.Upcoming) != "[1 3]" {
+		t.Errorf("expected days 1 and 3 to be queued again, got %v", state.Upcoming)
+	}
+	if repaired, err := checkGZCLPState(); err != nil || repaired {
+		t.Errorf("expected the event history to match the rewound state, repaired=%v (%v)", repaired, err)
+	}
+}
+
+// ---- GZCLP program history ----
+
+func postSkip(t *testing.T) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/gzclp/skip", nil)
+	w := httptest.NewRecorder()
+	skipGZCLPDay(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("skip failed: %d", w.Code)
+	}
+}
+
+func TestGZCLPHistory_RecordsEvents(t *testing.T) {
+	setupTestDB(t)
+	recordGZCLPDay(t, 1, false)
+	postSkip(t)
+
+	req := httptest.NewRequest("GET", "/api/gzclp/history", nil)
+	w := httptest.NewRecorder()
+	getGZCLPHistoryAPI(w, req)
+
+	var history GZCLPHistory
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+		t.Fatalf("failed to decode history: %v", err)
+	}
+	if len(history.Events) != 2 || history.Events[0].Type != "workout" || history.Events[1].Type != "skip" {
+		t.Fatalf("expected workout then skip events, got %+v", history.Events)
+	}
+	if history.State.CurrentDay != 3 || history.State.SkippedDays != 1 {
+		t.Errorf("expected derived state day 3 with 1 skip, got %+v", history.State)
+	}
+}
+
+func TestDeriveGZCLPState_Baseline(t *testing.T) {
+	state := deriveGZCLPState([]GZCLPEvent{
+		{Type: "baseline", ToDay: 3, Data: `{"current_day":3,"skipped_days":2}`},
+		{Type: "skip", FromDay: 3, ToDay: 4},
+		{Type: "skip", FromDay: 4, ToDay: 1, Undone: true},
+		{Type: "config", FromDay: 4, ToDay: 4},
+	})
+	if state.CurrentDay != 4 || state.SkippedDays != 3 {
+		t.Errorf("expected day 4 with 3 skips, got %+v", state)
+	}
+}
+
+func TestUndoGZCLPEvent_Skip(t *testing.T) {
+	setupTestDB(t)
+	recordGZCLPDay(t, 1, false)
+	postSkip(t)
+
+	req := httptest.NewRequest("POST", "/api/gzclp/undo", nil)
+	w := httptest.NewRecorder()
+	undoGZCLPEventAPI(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var day, skipped int
+	db.QueryRow("SELECT current_day, skipped_days FROM gzclp_settings WHERE id = 1").Scan(&day, &skipped)
+	if day != 2 || skipped != 0 {
+		t.Errorf("expected day 2 with no skips after undo, got day %d with %d skips", day, skipped)
+	}
+
+	// The workout is next in line, which has to be deleted instead
+	w = httptest.NewRecorder()
+	undoGZCLPEventAPI(w, httptest.NewRequest("POST", "/api/gzclp/undo", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 when the last event is a workout, got %d", w.Code)
+	}
+}
+
+func TestUndoGZCLPEvent_ConfigRestoresAssignments(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultGZCLPDayExercises()
+	t1 := getGZCLPDaySlots(1)[0].ExerciseName
+
+	body := `[{"day":1,"slot":"T1","exercise_name":"Front Squat"}]`
+	req := httptest.NewRequest("PUT", "/api/gzclp/config", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handleGZCLPConfigAPI(w, req)
+	if changed := getGZCLPDaySlots(1)[0].ExerciseName; changed != "Front Squat" {
+		t.Fatalf("expected config change to apply, got %s", changed)
+	}
+
+	w = httptest.NewRecorder()
+	undoGZCLPEventAPI(w, httptest.NewRequest("POST", "/api/gzclp/undo", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if restored := getGZCLPDaySlots(1)[0].ExerciseName; restored != t1 {
+		t.Errorf("expected T1 to be restored to %s, got %s", t1, restored)
+	}
+}
+
+func TestUndoGZCLPEvent_RejectsDeletedWorkout(t *testing.T) {
+	setupTestDB(t)
+	recordGZCLPDay(t, 1, false)
+	deleteWorkoutByID(t, recordGZCLPDay(t, 2, false))
+
+	w := httptest.NewRecorder()
+	undoGZCLPEventAPI(w, httptest.NewRequest("POST", "/api/gzclp/undo", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 when the last event is a deleted workout, got %d", w.Code)
+	}
+	if day, _ := getNextGZCLPWorkoutDay(); day != 2 {
+		t.Errorf("expected the rotation to stay on day 2, got %d", day)
+	}
+}
+
+func TestCheckGZCLPState_MatchesEvents(t *testing.T) {
+	setupTestDB(t)
+	recordGZCLPDay(t, 1, false)
+	postSkip(t)
+	deleteWorkoutByID(t, recordGZCLPDay(t, 3, false))
+
+	repaired, err := checkGZCLPState()
+	if err != nil || repaired {
+		t.Fatalf("expected settings to match the events, got repaired=%v err=%v", repaired, err)
+	}
+
+	db.Exec("UPDATE gzclp_settings SET current_day = 4, skipped_days = 0 WHERE id = 1")
+	if repaired, _ := checkGZCLPState(); !repaired {
+		t.Error("expected drifted settings to be repaired")
+	}
+	state, _ := loadGZCLPState(db)
+	if state.CurrentDay != 3 || state.SkippedDays != 1 {
+		t.Errorf("expected day 3 with 1 skip from the events, got %+v", state)
+	}
+}
+
+func TestApplyGZCLPEvent_OverrideDropsSwappedOrder(t *testing.T) {
+	setupTestDB(t)
+	postDayChange(t, `{"action":"swap"}`)
+	recordGZCLPDay(t, 4, true)
+
+	state, _ := loadGZCLPState(db)
+	if state.CurrentDay != 1 || len(state.Upcoming) != 0 {
+		t.Errorf("expected the override to continue from day 4 in normal order, got %+v", state)
+	}
+	if repaired, err := checkGZCLPState(); err != nil || repaired {
+		t.Errorf("expected settings to match the events, got repaired=%v err=%v", repaired, err)
+	}
+}
+
+func TestUndoGZCLPEvent_NothingToUndo(t *testing.T) {
+	setupTestDB(t)
+
+	w := httptest.NewRecorder()
+	undoGZCLPEventAPI(w, httptest.NewRequest("POST", "/api/gzclp/undo", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", w.Code)
+	}
+}
+
+// ---- Skip log and adherence ----
+
+func TestSkipGZCLPDay_LogsReason(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultGZCLPDayExercises()
+
+	form := url.Values{}
+	form.Set("date", "2026-03-04")
+	form.Set("reason", "travel")
+	form.Set("note", "Conference")
+	req := httptest.NewRequest("POST", "/gzclp/skip", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	skipGZCLPDay(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	skips, _ := getSkippedSessions("", "")
+	if len(skips) != 1 {
+		t.Fatalf("expected 1 skip, got %d", len(skips))
+	}
+	t1 := getGZCLPDaySlots(1)[0].ExerciseName
+	skip := skips[0]
+	if skip.Date != "2026-03-04" || skip.WorkoutDay != 1 || skip.Reason != "travel" || skip.Note != "Conference" {
+		t.Errorf("unexpected skip: %+v", skip)
+	}
+	if len(skip.Exercises) == 0 || skip.Exercises[0] != t1 {
+		t.Errorf("expected skipped exercises to start with %s, got %v", t1, skip.Exercises)
+	}
+
+	// Undoing the skip removes it from the log
+	undoLastGZCLPEvent()
+	if skips, _ := getSkippedSessions("", ""); len(skips) != 0 {
+		t.Errorf("expected undo to remove the skip, got %d", len(skips))
+	}
+}
+
+func TestSkipGZCLPDay_InvalidReason(t *testing.T) {
+	setupTestDB(t)
+
+	req := httptest.NewRequest("POST", "/gzclp/skip", strings.NewReader("reason=bored"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	skipGZCLPDay(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestBuildAdherenceReport(t *testing.T) {
+	setupTestDB(t)
+	// Week of 2026-03-02: two sessions done, one skipped for being sick
+	seedWorkout(t, "2026-03-02", "gzclp", 1, []Exercise{{Name: "Squat", Sets: []Set{{Reps: 3, Weight: 100}}}})
+	seedWorkout(t, "2026-03-04", "gzclp", 2, []Exercise{{Name: "Bench Press", Sets: []Set{{Reps: 3, Weight: 60}}}})
+	seedWorkout(t, "2026-03-05", "custom", 0, []Exercise{{Name: "Bicep Curl", Sets: []Set{{Reps: 12, Weight: 15}}}})
+	tx, _ := db.Begin()
+	insertSkippedSession(tx, SkippedSession{Date: "2026-03-06", WorkoutDay: 3, Reason: "sick"})
+	tx.Commit()
+
+	report, err := buildAdherenceReport("2026-03-02", "2026-03-15", 3)
+	if err != nil {
+		t.Fatalf("failed to build report: %v", err)
+	}
+	if len(report.Weeks) != 2 {
+		t.Fatalf("expected 2 weeks, got %d", len(report.Weeks))
+	}
+	first, second := report.Weeks[0], report.Weeks[1]
+	if first.Completed != 2 || first.Skipped != 1 || first.Missed != 0 || first.Rate != 66.7 {
+		t.Errorf("unexpected first week: %+v", first)
+	}
+	if second.Completed != 0 || second.Missed != 3 || second.Rate != 0 {
+		t.Errorf("unexpected second week: %+v", second)
+	}
+	// Six planned sessions over a four day rotation
+	for i, planned := range []int{2, 2, 1, 1} {
+		if report.Days[i].Planned != planned {
+			t.Errorf("expected day %d to be planned %d times, got %+v", i+1, planned, report.Days[i])
+		}
+	}
+	if day := report.Days[0]; day.Completed != 1 || day.Missed != 1 || day.Rate != 50 {
+		t.Errorf("unexpected day 1: %+v", day)
+	}
+	if day := report.Days[2]; day.Day != 3 || day.Skipped != 1 || day.Missed != 0 {
+		t.Errorf("unexpected day 3: %+v", day)
+	}
+	if report.Reasons["sick"] != 1 {
+		t.Errorf("expected one sick skip, got %v", report.Reasons)
+	}
+}
+
+// ---- Manual GZCLP day changes ----
+
+func postDayChange(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/api/gzclp/day", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	changeGZCLPDayAPI(w, req)
+	return w
+}
+
+func TestChangeGZCLPDay_SwapRunsBothDaysThenContinues(t *testing.T) {
+	setupTestDB(t)
+
+	if w := postDayChange(t, `{"action":"swap"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var order []int
+	for i := 0; i < 4; i++ {
+		day, _ := getNextGZCLPWorkoutDay()
+		order = append(order, day)
+		recordGZCLPDay(t, day, false)
+	}
+	if fmt.Sprint(order) != "[2 1 3 4]" {
+		t.Errorf("expected days 2, 1, 3, 4 after swapping, got %v", order)
+	}
+	if day, _ := getNextGZCLPWorkoutDay(); day != 1 {
+		t.Errorf("expected the rotation to wrap to day 1, got %d", day)
+	}
+}
+
+func TestChangeGZCLPDay_JumpAndBack(t *testing.T) {
+	setupTestDB(t)
+
+	postDayChange(t, `{"action":"jump","day":3}`)
+	if day, _ := getNextGZCLPWorkoutDay(); day != 3 {
+		t.Errorf("expected day 3 after jump, got %d", day)
+	}
+	postDayChange(t, `{"action":"back"}`)
+	if day, _ := getNextGZCLPWorkoutDay(); day != 2 {
+		t.Errorf("expected day 2 after going back, got %d", day)
+	}
+
+	events, _ := getGZCLPEvents(db)
+	if len(events) != 2 || events[0].Type != "day_change" || events[1].FromDay != 3 || events[1].ToDay != 2 {
+		t.Errorf("expected both changes to be recorded, got %+v", events)
+	}
+}
+
+func TestChangeGZCLPDay_Invalid(t *testing.T) {
+	setupTestDB(t)
+
+	for _, body := range []string{`{"action":"jump","day":5}`, `{"action":"shuffle"}`, `not json`} {
+		if w := postDayChange(t, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestChangeGZCLPDay_UndoRestoresOrder(t *testing.T) {
+	setupTestDB(t)
+	postDayChange(t, `{"action":"swap"}`)
+
+	if _, state, err := undoLastGZCLPEvent(); err != nil || state.CurrentDay != 1 || len(state.Upcoming) != 0 {
+		t.Errorf("expected undo to return to day 1 in normal order, got %+v (%v)", state, err)
+	}
+}
+
+func TestGZCLPForm_ShowsSwappedOrder(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultGZCLPDayExercises()
+	postDayChange(t, `{"action":"swap"}`)
+
+	w := httptest.NewRecorder()
+	gzclpForm(w, httptest.NewRequest("GET", "/gzclp", nil))
+	body := w.Body.String()
+	if !strings.Contains(body, "GZCLP Workout - Day 2") || !strings.Contains(body, "up next: Day 1, Day 3") {
+		t.Error("expected the form to show day 2 with the swapped order")
+	}
+}
+
+// ---- Configurable rotation and slots ----
+
+func putGZCLPConfig(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("PUT", "/api/gzclp/config", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handleGZCLPConfigAPI(w, req)
+	return w
+}
+
+const threeDayConfig = `{"days": 3, "slots": [
+	{"day": 1, "slot": "T1", "exercise_name": "Squat"},
+	{"day": 1, "slot": "T2", "exercise_name": "Bench Press"},
+	{"day": 1, "slot": "T3", "exercise_name": "Lat Pulldown"},
+	{"day": 1, "slot": "Curls", "exercise_name": "Bicep Curl"},
+	{"day": 1, "slot": "Calves", "exercise_name": "Calf Raise"},
+	{"day": 1, "slot": "Flyes", "exercise_name": "Chest Fly"},
+	{"day": 2, "slot": "T1", "exercise_name": "Overhead Press"},
+	{"day": 2, "slot": "T2", "exercise_name": "Deadlift"},
+	{"day": 3, "slot": "Main", "tier": "T1", "exercise_name": "Bench Press"}
+]}`
+
+func TestGZCLPConfigAPI_ReplacesLayout(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultGZCLPDayExercises()
+
+	if w := putGZCLPConfig(t, threeDayConfig); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	config, _ := getGZCLPConfig()
+	if config.Days != 3 || len(config.Slots) != 9 {
+		t.Fatalf("expected 3 days with 9 slots, got %d days with %d slots", config.Days, len(config.Slots))
+	}
+	day1 := getGZCLPDaySlots(1)
+	if got := strings.Join(slotExerciseNames(day1), ","); got != "Squat,Bench Press,Lat Pulldown,Bicep Curl,Calf Raise,Chest Fly" {
+		t.Errorf("unexpected day 1 order: %s", got)
+	}
+	if day1[5].Position != 5 || day1[5].Tier != "" {
+		t.Errorf("expected the last accessory at position 5 without a tier, got %+v", day1[5])
+	}
+	if day3 := getGZCLPDaySlots(3); len(day3) != 1 || day3[0].Tier != "T1" {
+		t.Errorf("expected day 3 to have one T1 slot, got %+v", day3)
+	}
+}
+
+func TestGZCLPConfigAPI_RotationLength(t *testing.T) {
+	setupTestDB(t)
+	putGZCLPConfig(t, threeDayConfig)
+
+	for _, day := range []int{1, 2, 3} {
+		recordGZCLPDay(t, day, false)
+	}
+	if day, _ := getNextGZCLPWorkoutDay(); day != 1 {
+		t.Errorf("expected a 3-day rotation to wrap to day 1, got %d", day)
+	}
+	if w := postDayChange(t, `{"action":"jump","day":4}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected jumping past the rotation to fail, got %d", w.Code)
+	}
+	if w := postDayChange(t, `{"action":"back"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if day, _ := getNextGZCLPWorkoutDay(); day != 3 {
+		t.Errorf("expected going back from day 1 to reach day 3, got %d", day)
+	}
+}
+
+func TestGZCLPConfigAPI_ShrinkingMovesCurrentDay(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultGZCLPDayExercises()
+	db.Exec("UPDATE gzclp_settings SET current_day = 4, upcoming_days = '[2]' WHERE id = 1")
+
+	putGZCLPConfig(t, threeDayConfig)
+	state, _ := loadGZCLPState(db)
+	if state.CurrentDay != 1 || len(state.Upcoming) != 0 || state.Days != 3 {
+		t.Errorf("expected day 1 of 3 with no queue, got %+v", state)
+	}
+
+	_, state, err := undoLastGZCLPEvent()
+	if err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+	if state.Days != 4 || len(getGZCLPDaySlots(4)) != 5 {
+		t.Errorf("expected undo to restore the 4-day layout, got %+v", state)
+	}
+	if day1 := getGZCLPDaySlots(1); len(day1) != 5 || day1[0].Tier != "T1" {
+		t.Errorf("expected day 1 slots to be restored, got %+v", day1)
+	}
+}
+
+func TestGZCLPConfigAPI_RejectsInvalidLayout(t *testing.T) {
+	setupTestDB(t)
+
+	for _, body := range []string{
+		`{"days": 0, "slots": []}`,
+		`{"days": 8, "slots": []}`,
+		`{"days": 3, "slots": [{"day": 4, "slot": "T1", "exercise_name": "Squat"}]}`,
+		`{"days": 3, "slots": [{"day": 1, "slot": "T1", "exercise_name": "Squat"}, {"day": 1, "slot": "T1", "exercise_name": "Deadlift"}]}`,
+		`{"days": 3, "slots": [{"day": 1, "slot": "Main", "tier": "T4", "exercise_name": "Squat"}]}`,
+		`[{"day": 5, "slot": "T1", "exercise_name": "Squat"}]`,
+	} {
+		if w := putGZCLPConfig(t, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestGZCLPConfigAPI_PartialUpdateAppendsNewSlot(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultGZCLPDayExercises()
+
+	putGZCLPConfig(t, `[{"day": 1, "slot": "Additional3", "exercise_name": "Calf Raise"}, {"day": 1, "slot": "T1", "exercise_name": "Front Squat"}]`)
+	slots := getGZCLPDaySlots(1)
+	if len(slots) != 6 || slots[0].ExerciseName != "Front Squat" || slots[0].Tier != "T1" {
+		t.Fatalf("expected T1 to change in place, got %+v", slots)
+	}
+	if slots[5].Slot != "Additional3" || slots[5].Position != 5 {
+		t.Errorf("expected the new slot to be appended, got %+v", slots[5])
+	}
+}
+
+func TestGZCLPForm_RendersConfiguredSlots(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+	putGZCLPConfig(t, threeDayConfig)
+
+	w := httptest.NewRecorder()
+	gzclpForm(w, httptest.NewRequest("GET", "/gzclp", nil))
+	body := w.Body.String()
+	if !strings.Contains(body, "Workout 1 of 3") {
+		t.Error("expected the form to show a 3-day rotation")
+	}
+	if !strings.Contains(body, `name="exercise_5"`) || strings.Contains(body, `name="exercise_6"`) {
+		t.Error("expected exactly six exercise blocks")
+	}
+	if !strings.Contains(body, "Optional Exercise 3") || !strings.Contains(body, `name="weight_0_4"`) {
+		t.Error("expected three accessories and five T1 sets")
+	}
+	if !strings.Contains(body, `<option value="3">Day 3</option>`) || strings.Contains(body, `<option value="4">Day 4</option>`) {
+		t.Error("expected jump options for days 1-3 only")
+	}
+}
+
+// ---- Tier per logged exercise ----
+
+func TestCreateWorkout_StoresSlotAndTier(t *testing.T) {
+	setupTestDB(t)
+
+	form := url.Values{}
+	form.Set("date", "2026-03-04")
+	form.Set("workout_type", "gzclp")
+	form.Set("workout_day", "1")
+	form.Set("exercise_0", "Squat")
+	form.Set("slot_0", "T1")
+	form.Set("tier_0", "T1")
+	form.Set("stage_0", "5x3")
+	form.Set("weight_0_0", "100")
+	form.Set("reps_0_0", "3")
+	form.Set("exercise_1", "Bicep Curl")
+	form.Set("slot_1", "Curls")
+	form.Set("weight_1_0", "12")
+	form.Set("reps_1_0", "15")
+	req := httptest.NewRequest("POST", "/workout/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	createWorkout(w, req)
+
+	workouts, _ := getWorkoutsFromDB()
+	if len(workouts) != 1 || len(workouts[0].Exercises) != 2 {
+		t.Fatalf("expected one workout with 2 exercises, got %+v", workouts)
+	}
+	squat, curls := workouts[0].Exercises[0], workouts[0].Exercises[1]
+	if squat.Slot != "T1" || squat.Tier != "T1" || curls.Slot != "Curls" || curls.Tier != "" {
+		t.Errorf("unexpected slots and tiers: %+v, %+v", squat, curls)
+	}
+	if squat.Stage != "5x3" || curls.Stage != "" {
+		t.Errorf("expected the squat to be logged at 5x3, got %+v, %+v", squat, curls)
+	}
+
+	form.Set("stage_0", "five")
+	req = httptest.NewRequest("POST", "/workout/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	createWorkout(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid stage, got %d", w.Code)
+	}
+
+	form.Set("stage_0", "5x3")
+	form.Set("tier_0", "T9")
+	req = httptest.NewRequest("POST", "/workout/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	createWorkout(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown tier, got %d", w.Code)
+	}
+}
+
+func TestLatestExerciseAPI_FiltersByTier(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-10", "gzclp", 1, []Exercise{
+		{Name: "Squat", Tier: "T1", Sets: []Set{{Weight: 120, Reps: 3}}},
+	})
+	seedWorkout(t, "2026-03-12", "gzclp", 3, []Exercise{
+		{Name: "Squat", Tier: "T2", Sets: []Set{{Weight: 90, Reps: 10}}},
+	})
+
+	latest := func(query string) []Set {
+		w := httptest.NewRecorder()
+		getLatestExercise(w, httptest.NewRequest("GET", "/api/latest-exercise?"+query, nil))
+		var result struct {
+			Sets []Set `json:"sets"`
+		}
+		json.NewDecoder(w.Body).Decode(&result)
+		return result.Sets
+	}
+
+	if sets := latest("name=Squat&tier=T1"); len(sets) != 1 || sets[0].Weight != 120 {
+		t.Errorf("expected the T1 session, got %+v", sets)
+	}
+	if sets := latest("name=Squat&tier=T2"); len(sets) != 1 || sets[0].Weight != 90 {
+		t.Errorf("expected the T2 session, got %+v", sets)
+	}
+	if sets := latest("name=Squat"); len(sets) != 1 || sets[0].Weight != 90 {
+		t.Errorf("expected the latest session without a tier filter, got %+v", sets)
+	}
+
+	w := httptest.NewRecorder()
+	getLatestExercise(w, httptest.NewRequest("GET", "/api/latest-exercise?name=Squat&tier=T4", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown tier, got %d", w.Code)
+	}
+}
+
+func TestStatisticsAPI_FiltersByTier(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-10", "gzclp", 1, []Exercise{
+		{Name: "Squat", Tier: "T1", Sets: []Set{{Weight: 120, Reps: 3}}},
+	})
+	seedWorkout(t, "2026-03-12", "gzclp", 3, []Exercise{
+		{Name: "Squat", Tier: "T2", Sets: []Set{{Weight: 90, Reps: 10}}},
+	})
+
+	w := httptest.NewRecorder()
+	getStatisticsData(w, httptest.NewRequest("GET", "/api/statistics?exercise=Squat&tier=T2", nil))
+	var response StatisticsResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Data) != 1 || response.Data[0].Date != "2026-03-12" || response.Tier != "T2" {
+		t.Errorf("expected only the T2 session, got %+v", response)
+	}
+
+	w = httptest.NewRecorder()
+	getStatisticsData(w, httptest.NewRequest("GET", "/api/statistics?exercise=Squat&tier=X", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown tier, got %d", w.Code)
+	}
+}
+
+func TestSyncWorkouts_KeepsTier(t *testing.T) {
+	setupTestDB(t)
+
+	result, err := syncWorkout(SyncItem{ClientID: "tier-1", Workout: Workout{Date: "2026-03-04", Exercises: []Exercise{
+		{Name: "Bench Press", Slot: "T1", Tier: "T1", Sets: []Set{{Weight: 80, Reps: 3}}},
+	}}})
+	if err != nil || result.Status != "created" {
+		t.Fatalf("expected the workout to be created, got %+v (%v)", result, err)
+	}
+	workout, _ := getWorkoutByID(result.WorkoutID)
+	if workout.Exercises[0].Tier != "T1" || workout.Exercises[0].Slot != "T1" {
+		t.Errorf("expected the tier to be stored, got %+v", workout.Exercises[0])
+	}
+
+	result, _ = syncWorkout(SyncItem{ClientID: "tier-2", Workout: Workout{Date: "2026-03-04", Exercises: []Exercise{
+		{Name: "Bench Press", Tier: "T5", Sets: []Set{{Weight: 80, Reps: 3}}},
+	}}})
+	if result.Status != "invalid" {
+		t.Errorf("expected an unknown tier to be invalid, got %+v", result)
+	}
+}
+
+// ---- Repeated exercises ----
+
+func TestGetWorkoutsFromDB_RepeatedExercise(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-04", "custom", 0, []Exercise{
+		{Name: "Bench Press", Sets: []Set{{Weight: 100, Reps: 3}, {Weight: 100, Reps: 3}}},
+		{Name: "Bicep Curl", Sets: []Set{{Weight: 12, Reps: 12}}},
+		{Name: "Bench Press", Sets: []Set{{Weight: 80, Reps: 10}}},
+	})
+
+	workouts, err := getWorkoutsFromDB()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exercises := workouts[0].Exercises
+	if len(exercises) != 3 {
+		t.Fatalf("expected 3 exercises, got %d", len(exercises))
+	}
+	if exercises[0].Name != "Bench Press" || len(exercises[0].Sets) != 2 || exercises[0].Sets[0].Weight != 100 {
+		t.Errorf("unexpected heavy bench entry: %+v", exercises[0])
+	}
+	if exercises[2].Name != "Bench Press" || len(exercises[2].Sets) != 1 || exercises[2].Sets[0].Weight != 80 {
+		t.Errorf("unexpected back-off bench entry: %+v", exercises[2])
+	}
+	if exercises[0].ID == 0 || exercises[0].ID == exercises[2].ID {
+		t.Errorf("expected distinct exercise row IDs, got %d and %d", exercises[0].ID, exercises[2].ID)
+	}
+
+	byID, _ := getWorkoutByID(workouts[0].ID)
+	for i := range byID.Exercises {
+		if byID.Exercises[i].ID != exercises[i].ID {
+			t.Errorf("exercise %d: expected ID %d, got %d", i, exercises[i].ID, byID.Exercises[i].ID)
+		}
+	}
+}
+
+func TestGetWorkoutsFromDB_OrderAndEmptyWorkouts(t *testing.T) {
+	setupTestDB(t)
+	first := seedWorkout(t, "2026-03-04", "custom", 0, []Exercise{{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}}})
+	db.Exec("INSERT INTO workouts (date, workout_type) VALUES ('2026-03-06', 'custom')")
+	second := seedWorkout(t, "2026-03-04", "custom", 0, []Exercise{{Name: "Deadlift", Sets: []Set{{Weight: 140, Reps: 5}}}})
+
+	workouts, err := getWorkoutsFromDB()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(workouts) != 3 {
+		t.Fatalf("expected 3 workouts, got %d", len(workouts))
+	}
+	if workouts[0].Date != "2026-03-06" || len(workouts[0].Exercises) != 0 {
+		t.Errorf("expected the empty workout first, got %+v", workouts[0])
+	}
+	if workouts[1].ID != second || workouts[2].ID != first {
+		t.Errorf("expected same-day workouts newest first, got %d then %d", workouts[1].ID, workouts[2].ID)
+	}
+}
+
+func TestExport_KeepsRepeatedExercises(t *testing.T) {
+	setupTestDB(t)
+	seedWorkout(t, "2026-03-04", "custom", 0, []Exercise{
+		{Name: "Bench Press", Sets: []Set{{Weight: 100, Reps: 3}}},
+		{Name: "Bench Press", Sets: []Set{{Weight: 80, Reps: 10}}},
+	})
+
+	w := httptest.NewRecorder()
+	exportData(w, httptest.NewRequest("GET", "/api/export", nil))
+	var export ExportData
+	if err := json.NewDecoder(w.Body).Decode(&export); err != nil {
+		t.Fatalf("failed to decode export: %v", err)
+	}
+	exercises := export.Workouts[0].Exercises
+	if len(exercises) != 2 || exercises[1].Sets[0].Weight != 80 || exercises[0].ID == exercises[1].ID {
+		t.Errorf("expected both bench entries with their own sets, got %+v", exercises)
+	}
+}
+
+// ---- Training maxes and percentage prescriptions ----
+
+func postTrainingMax(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/api/training-max", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handleTrainingMaxAPI(w, req)
+	return w
+}
+
+func TestTrainingMaxAPI_History(t *testing.T) {
+	setupTestDB(t)
+	postTrainingMax(t, `{"exercise_name": "Squat", "value": 140, "date": "2026-03-01"}`)
+	postTrainingMax(t, `{"exercise_name": "Squat", "value": 145, "date": "2026-04-01", "note": "after test day"}`)
+	postTrainingMax(t, `{"exercise_name": "Bench Press", "value": 100, "date": "2026-03-01"}`)
+
+	req := httptest.NewRequest("GET", "/api/training-max?exercise=Squat&percent=75", nil)
+	w := httptest.NewRecorder()
+	handleTrainingMaxAPI(w, req)
+	var response TrainingMaxResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.History) != 2 || response.Current == nil || response.Current.Value != 145 {
+		t.Fatalf("expected two entries with 145 current, got %+v", response)
+	}
+	// 75% of 145 is 108.75, rounded to the nearest 2.5
+	if response.Weight != 110 {
+		t.Errorf("expected 110 kg at 75%%, got %v", response.Weight)
+	}
+
+	w = httptest.NewRecorder()
+	handleTrainingMaxAPI(w, httptest.NewRequest("GET", "/api/training-max", nil))
+	var current []TrainingMax
+	json.NewDecoder(w.Body).Decode(&current)
+	if len(current) != 2 || current[0].ExerciseName != "Bench Press" || current[1].Value != 145 {
+		t.Errorf("expected the latest entry per exercise, got %+v", current)
+	}
+}
+
+func TestTrainingMaxAPI_Validation(t *testing.T) {
+	setupTestDB(t)
+	for _, body := range []string{
+		`{"exercise_name": "", "value": 100}`,
+		`{"exercise_name": "Squat", "value": 0}`,
+		`{"exercise_name": "Squat", "value": 100, "date": "yesterday"}`,
+	} {
+		if w := postTrainingMax(t, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handleTrainingMaxAPI(w, httptest.NewRequest("DELETE", "/api/training-max?id=99", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 deleting a missing entry, got %d", w.Code)
+	}
+}
+
+func TestWorkoutTemplate_PercentOfTrainingMax(t *testing.T) {
+	setupTestDB(t)
+	req := httptest.NewRequest("POST", "/api/templates", strings.NewReader(
+		`{"name": "Squat Day", "exercises": [{"exercise_name": "Squat", "sets": 3, "reps": 5, "weight": 60, "percent": 75}]}`))
+	w := httptest.NewRecorder()
+	handleTemplatesAPI(w, req)
+	var created WorkoutTemplate
+	json.NewDecoder(w.Body).Decode(&created)
+
+	saved, err := getWorkoutTemplate(created.ID)
+	if err != nil || saved.Exercises[0].Percent != 75 {
+		t.Fatalf("expected the percent to be stored, got %+v (%v)", saved, err)
+	}
+	// Without a training max the fixed weight is used
+	if sets := saved.toExercises(nil, nil)[0].Sets; sets[0].Weight != 60 {
+		t.Errorf("expected the 60 kg fallback, got %v", sets[0].Weight)
+	}
+	if sets := saved.toExercises(map[string]float64{"Squat": 140}, nil)[0].Sets; len(sets) != 3 || sets[0].Weight != 105 {
+		t.Errorf("expected 3 sets at 105 kg, got %+v", sets)
+	}
+
+	req = httptest.NewRequest("POST", "/api/templates", strings.NewReader(
+		`{"name": "Too Heavy", "exercises": [{"exercise_name": "Squat", "sets": 3, "reps": 5, "percent": 200}]}`))
+	w = httptest.NewRecorder()
+	handleTemplatesAPI(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for 200%%, got %d", w.Code)
+	}
+}
+
+func TestGZCLPSlots_PercentPrescription(t *testing.T) {
+	setupTestDB(t)
+	w := putGZCLPConfig(t, `{"days": 1, "slots": [
+		{"day": 1, "slot": "T1", "exercise_name": "Squat", "sets": 3, "reps": 5, "percent": 85},
+		{"day": 1, "slot": "T2", "exercise_name": "Bench Press"}
+	]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	postTrainingMax(t, `{"exercise_name": "Squat", "value": 150}`)
+
+	trainingMaxes, _ := getCurrentTrainingMaxes()
+	slots := gzclpFormSlots(getGZCLPDaySlots(1), trainingMaxes, nil, nil)
+	if len(slots[0].Sets) != 3 || slots[0].Reps != 5 || slots[0].Weight != 127.5 {
+		t.Errorf("expected T1 as 3x5 @ 127.5, got %d sets of %d @ %v", len(slots[0].Sets), slots[0].Reps, slots[0].Weight)
+	}
+	if len(slots[1].Sets) != 3 || slots[1].Reps != 10 || slots[1].Weight != 0 {
+		t.Errorf("expected T2 defaults without a weight, got %+v", slots[1])
+	}
+	if slots[0].Stage != "3x5" || slots[1].Stage != "3x10" {
+		t.Errorf("expected stages 3x5 and 3x10, got %s and %s", slots[0].Stage, slots[1].Stage)
+	}
+
+	rec := httptest.NewRecorder()
+	gzclpForm(rec, httptest.NewRequest("GET", "/gzclp", nil))
+	if !strings.Contains(rec.Body.String(), `name="weight_0_0" step="0.5" min="0" value="127.5"`) {
+		t.Error("expected the T1 weight inputs to be prefilled")
+	}
+
+	if w := putGZCLPConfig(t, `{"days": 1, "slots": [{"day": 1, "slot": "T1", "exercise_name": "Squat", "percent": -5}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a negative percent, got %d", w.Code)
+	}
+}
+
+// ---- Progression increments ----
+
+func TestExerciseIncrement_Defaults(t *testing.T) {
+	tests := []struct {
+		exercise ExerciseDB
+		want     float64
+	}{
+		{ExerciseDB{PrimaryMuscles: []string{"quads", "glutes"}, Equipment: "barbell"}, 5},
+		{ExerciseDB{PrimaryMuscles: []string{"quads", "glutes"}, Equipment: "machine"}, 5},
+		{ExerciseDB{PrimaryMuscles: []string{"chest"}, Equipment: "barbell"}, 2.5},
+		{ExerciseDB{PrimaryMuscles: []string{"shoulders"}, Equipment: "barbell"}, 1.25},
+		{ExerciseDB{PrimaryMuscles: []string{"shoulders"}, Equipment: "dumbbell"}, 2.5},
+		{ExerciseDB{}, 2.5},
+		{ExerciseDB{PrimaryMuscles: []string{"quads"}, Equipment: "barbell", Increment: 2.5}, 2.5},
+	}
+	for _, tt := range tests {
+		if got := exerciseIncrement(tt.exercise); got != tt.want {
+			t.Errorf("exerciseIncrement(%+v) = %v, want %v", tt.exercise, got, tt.want)
+		}
+	}
+}
+
+func TestExercisesAPI_Increment(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+
+	exercises, _ := getAllExercises()
+	byName := map[string]ExerciseDB{}
+	for _, e := range exercises {
+		byName[e.Name] = e
+	}
+	if byName["Squat"].ProgressionIncrement != 5 || byName["Bench Press"].ProgressionIncrement != 2.5 ||
+		byName["Overhead Press"].ProgressionIncrement != 1.25 {
+		t.Errorf("unexpected default increments: squat %v, bench %v, press %v", byName["Squat"].ProgressionIncrement,
+			byName["Bench Press"].ProgressionIncrement, byName["Overhead Press"].ProgressionIncrement)
+	}
+
+	squat := byName["Squat"]
+	body := fmt.Sprintf(`{"id": %d, "name": "Squat", "primary_muscles": ["quads"], "equipment": "barbell", "increment": 2.5}`, squat.ID)
+	w := httptest.NewRecorder()
+	handleExercisesAPI(w, httptest.NewRequest("PUT", "/api/exercises", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	handleExercisesAPI(w, httptest.NewRequest("GET", "/api/exercises", nil))
+	if !strings.Contains(w.Body.String(), `"name":"Squat","is_default":true,"primary_muscles":["quads"],"secondary_muscles":[],"equipment":"barbell","movement_pattern":"","increment":2.5,"progression_increment":2.5`) {
+		t.Errorf("expected the squat override in /api/exercises, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handleExercisesAPI(w, httptest.NewRequest("POST", "/api/exercises", strings.NewReader(`{"name": "Sled Push", "increment": -1}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a negative increment, got %d", w.Code)
+	}
+}
+
+func TestLatestExerciseAPI_ProgressionSuggestion(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+	seedWorkout(t, "2026-03-02", "custom", 0, []Exercise{
+		{Name: "Overhead Press", Sets: []Set{{Weight: 42.5, Reps: 5}}},
+	})
+
+	req := httptest.NewRequest("GET", "/api/latest-exercise?name=Overhead+Press&deload=true", nil)
+	w := httptest.NewRecorder()
+	getLatestExercise(w, req)
+
+	var resp struct {
+		Increment float64 `json:"increment"`
+		Sets      []struct {
+			NextWeight   float64 `json:"next_weight"`
+			DeloadWeight float64 `json:"deload_weight"`
+		} `json:"sets"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	// 90% of 42.5 is 38.25, which microplates round to 38.75
+	if resp.Increment != 1.25 || resp.Sets[0].NextWeight != 43.75 || resp.Sets[0].DeloadWeight != 38.75 {
+		t.Errorf("expected +1.25 to 43.75 and a 38.75 deload, got %+v", resp)
+	}
+}
+
+func TestNewWorkoutForm_RepeatWithProgression(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+	id := seedWorkout(t, "2026-03-02", "custom", 0, []Exercise{
+		{Name: "Squat", Sets: []Set{{Weight: 100, Reps: 5}}},
+		{Name: "Overhead Press", Sets: []Set{{Weight: 40, Reps: 5}}},
+	})
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/workout/new?repeat=%d&progress=true", id), nil)
+	w := httptest.NewRecorder()
+	newWorkoutForm(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `"weight":105`) || !strings.Contains(body, `"weight":41.25`) {
+		t.Error("expected squat +5 kg and overhead press +1.25 kg")
+	}
+	if getSettings().RepeatIncrement != 0 {
+		t.Errorf("expected repeats to default to per-exercise increments, got %v", getSettings().RepeatIncrement)
+	}
+}
+
+// ---- GZCLP resets ----
+
+func TestGZCLPStage(t *testing.T) {
+	tests := []struct {
+		tier  string
+		reps  []int
+		stage string
+	}{
+		{"T1", []int{3, 3, 3, 3, 5}, "5x3"},
+		{"T1", []int{2, 2, 2, 2, 2, 2}, "6x2"},
+		{"T1", []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 0}, "10x1"},
+		{"T2", []int{10, 10, 8}, "3x10"},
+		{"T2", []int{8, 8, 7}, "3x8"},
+		{"T2", []int{6, 5, 4}, "3x6"},
+	}
+	for _, tt := range tests {
+		if stage := gzclpStage(tt.tier, tt.reps); stage != tt.stage {
+			t.Errorf("gzclpStage(%s, %v) = %s, want %s", tt.tier, tt.reps, stage, tt.stage)
+		}
+	}
+}
+
+func tenSingles(weight float64, lastReps int) []Set {
+	sets := make([]Set, 10)
+	for i := range sets {
+		sets[i] = Set{Weight: weight, Reps: 1}
+	}
+	sets[9].Reps = lastReps
+	return sets
+}
+
+func getGZCLPResetStatusAPI(t *testing.T) GZCLPResetStatus {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleGZCLPResetsAPI(w, httptest.NewRequest("GET", "/api/gzclp/resets", nil))
+	var status GZCLPResetStatus
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("failed to decode reset status: %v", err)
+	}
+	return status
+}
+
+func postGZCLPReset(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handleGZCLPResetsAPI(w, httptest.NewRequest("POST", "/api/gzclp/resets", strings.NewReader(body)))
+	return w
+}
+
+func TestGZCLPResets_T1FiveRepMaxTest(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+	seedWorkout(t, "2026-03-02", "gzclp", 1, []Exercise{{Name: "Squat", Tier: "T1", Sets: tenSingles(115, 1)}})
+	if status := getGZCLPResetStatusAPI(t); len(status.Pending) != 0 {
+		t.Fatalf("expected no reset after a completed 10x1, got %+v", status.Pending)
+	}
+
+	seedWorkout(t, "2026-03-06", "gzclp", 1, []Exercise{{Name: "Squat", Tier: "T1", Sets: tenSingles(120, 0)}})
+	status := getGZCLPResetStatusAPI(t)
+	if len(status.Pending) != 1 || status.Pending[0].FailedStage != "10x1" || status.Pending[0].FailedWeight != 120 {
+		t.Fatalf("expected a pending 10x1 reset at 120 kg, got %+v", status.Pending)
+	}
+
+	if w := postGZCLPReset(t, `{"exercise_name": "Squat", "tier": "T1"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a 5RM result, got %d", w.Code)
+	}
+	w := postGZCLPReset(t, `{"exercise_name": "Squat", "tier": "T1", "test_weight": 110, "date": "2026-03-09"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var reset GZCLPReset
+	json.NewDecoder(w.Body).Decode(&reset)
+	// 85% of 110 is 93.5
+	if reset.NewWeight != 92.5 || reset.TestWeight != 110 || reset.EventID == 0 {
+		t.Errorf("expected a restart at 92.5 kg from a 110 kg 5RM, got %+v", reset)
+	}
+
+	status = getGZCLPResetStatusAPI(t)
+	if len(status.Pending) != 0 || len(status.Restarts) != 1 || len(status.History) != 1 {
+		t.Errorf("expected the reset to be recorded, got %+v", status)
+	}
+	rec := httptest.NewRecorder()
+	gzclpForm(rec, httptest.NewRequest("GET", "/gzclp", nil))
+	if !strings.Contains(rec.Body.String(), `name="weight_0_0" step="0.5" min="0" value="92.5"`) {
+		t.Error("expected the T1 squat to start from the restart weight")
+	}
+	if w := postGZCLPReset(t, `{"exercise_name": "Squat", "tier": "T1", "test_weight": 110}`); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 resetting twice, got %d", w.Code)
+	}
+
+	// Undoing the reset puts it back to pending
+	if _, _, err := undoLastGZCLPEvent(); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+	if status := getGZCLPResetStatusAPI(t); len(status.Pending) != 1 || len(status.History) != 0 {
+		t.Errorf("expected the reset to be pending again after undo, got %+v", status)
+	}
+}
+
+func TestGZCLPResets_FailureIsOnlyResetOnce(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+	seedWorkout(t, "2026-03-02", "gzclp", 1, []Exercise{{Name: "Squat", Tier: "T1", Stage: "10x1", Sets: tenSingles(120, 0)}})
+	if w := postGZCLPReset(t, `{"exercise_name": "Squat", "tier": "T1", "test_weight": 110}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A second submit that read the failure as pending before the first saved
+	var reset GZCLPReset
+	db.QueryRow("SELECT workout_id, failed_stage, failed_weight, new_weight, date FROM gzclp_resets").
+		Scan(&reset.WorkoutID, &reset.FailedStage, &reset.FailedWeight, &reset.NewWeight, &reset.Date)
+	_, err := db.Exec(`INSERT INTO gzclp_resets (exercise_name, tier, workout_id, failed_stage, failed_weight, new_weight, date)
+		VALUES ('Squat', 'T1', ?, ?, ?, ?, ?)`, reset.WorkoutID, reset.FailedStage, reset.FailedWeight, reset.NewWeight, reset.Date)
+	if err == nil {
+		t.Error("expected a second reset of the same failure to be rejected")
+	}
+	if status := getGZCLPResetStatusAPI(t); len(status.History) != 1 {
+		t.Errorf("expected one reset in the history, got %+v", status.History)
+	}
+}
+
+func TestGZCLPResets_T2BackToThreeByTen(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+	seedWorkout(t, "2026-03-02", "gzclp", 1, []Exercise{{Name: "Bench Press", Tier: "T2", Sets: []Set{{Weight: 60, Reps: 10}, {Weight: 60, Reps: 10}, {Weight: 60, Reps: 10}}}})
+	seedWorkout(t, "2026-03-09", "gzclp", 1, []Exercise{{Name: "Bench Press", Tier: "T2", Sets: []Set{{Weight: 65, Reps: 8}, {Weight: 65, Reps: 8}, {Weight: 65, Reps: 8}}}})
+	seedWorkout(t, "2026-03-16", "gzclp", 1, []Exercise{{Name: "Bench Press", Tier: "T2", Sets: []Set{{Weight: 70, Reps: 6}, {Weight: 70, Reps: 5}, {Weight: 70, Reps: 4}}}})
+
+	status := getGZCLPResetStatusAPI(t)
+	// Last 3x10 was 60 kg, plus two 2.5 kg bench increments
+	if len(status.Pending) != 1 || status.Pending[0].FailedStage != "3x6" || status.Pending[0].NewWeight != 65 {
+		t.Fatalf("expected a pending 3x6 reset to 65 kg, got %+v", status.Pending)
+	}
+
+	w := postGZCLPReset(t, `{"exercise_name": "Bench Press", "tier": "T2"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	handleGZCLPResetsAPI(w, httptest.NewRequest("GET", "/api/gzclp/resets?exercise=Bench+Press", nil))
+	json.NewDecoder(w.Body).Decode(&status)
+	if len(status.History) != 1 || status.History[0].NewWeight != 65 || status.History[0].FailedDate != "2026-03-16" {
+		t.Errorf("expected one bench reset in the history, got %+v", status.History)
+	}
+
+	events, _ := getGZCLPEvents(db)
+	if last := events[len(events)-1]; last.Type != "reset" || !strings.Contains(last.Detail, "Bench Press to 3x10 at 65 kg") {
+		t.Errorf("expected a reset event in the program history, got %+v", last)
+	}
+
+	// The next 3x10 session clears the restart
+	seedWorkout(t, "2026-03-23", "gzclp", 1, []Exercise{{Name: "Bench Press", Tier: "T2", Sets: []Set{{Weight: 65, Reps: 10}, {Weight: 65, Reps: 10}, {Weight: 65, Reps: 10}}}})
+	if status := getGZCLPResetStatusAPI(t); len(status.Restarts) != 0 || len(status.Pending) != 0 {
+		t.Errorf("expected nothing outstanding after the restart session, got %+v", status)
+	}
+}
+
+func TestGZCLPProgress_ThreeByTenMissedByOneRep(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+	seedWorkout(t, "2026-03-02", "gzclp", 1, []Exercise{
+		{Name: "Bench Press", Tier: "T2", Stage: "3x10", Sets: []Set{{Weight: 60, Reps: 9}, {Weight: 60, Reps: 9}, {Weight: 60, Reps: 9}}},
+	})
+
+	progress, err := getGZCLPProgress()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stage := progress.Stages["T2/Bench Press"]; stage != "3x8" {
+		t.Fatalf("expected 9,9,9 at 3x10 to move bench to 3x8, got %q", stage)
+	}
+
+	slots := gzclpFormSlots([]GZCLPDayExercise{{Day: 1, Slot: "T2", Tier: "T2", ExerciseName: "Bench Press"}}, nil, nil, progress.Stages)
+	if len(slots[0].Sets) != 3 || slots[0].Reps != 8 || slots[0].Stage != "3x8" {
+		t.Errorf("expected the form to prescribe 3x8, got %d sets of %d at %q", len(slots[0].Sets), slots[0].Reps, slots[0].Stage)
+	}
+}
+
+func TestGZCLPProgress_TenSinglesWithMissingSets(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+	// Finished drafts drop unlogged sets, so a failed 10x1 can arrive as 8 singles
+	seedWorkout(t, "2026-03-02", "gzclp", 1, []Exercise{
+		{Name: "Squat", Tier: "T1", Stage: "10x1", Sets: tenSingles(120, 1)[:8]},
+	})
+
+	status := getGZCLPResetStatusAPI(t)
+	if len(status.Pending) != 1 || status.Pending[0].FailedStage != "10x1" {
+		t.Fatalf("expected a pending 10x1 reset, got %+v", status.Pending)
+	}
+}
+
+func TestGZCLPResets_PendingUntilSavedOrDismissed(t *testing.T) {
+	setupTestDB(t)
+	populateDefaultExercises()
+	seedWorkout(t, "2026-03-02", "gzclp", 1, []Exercise{{Name: "Squat", Tier: "T1", Stage: "10x1", Sets: tenSingles(120, 0)}})
+	seedWorkout(t, "2026-03-06", "gzclp", 1, []Exercise{{Name: "Squat", Tier: "T1", Stage: "10x1", Sets: tenSingles(110, 1)}})
+
+	status := getGZCLPResetStatusAPI(t)
+	if len(status.Pending) != 1 || status.Pending[0].FailedWeight != 120 {
+		t.Fatalf("expected the 120 kg failure to stay pending after another session, got %+v", status.Pending)
+	}
+
+	w := postGZCLPReset(t, `{"exercise_name": "Squat", "tier": "T1", "dismissed": true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	status = getGZCLPResetStatusAPI(t)
+	if len(status.Pending) != 0 || len(status.Restarts) != 0 || len(status.History) != 1 || !status.History[0].Dismissed {
+		t.Errorf("expected a dismissed reset without a restart, got %+v", status)
+	}
+	if progress, _ := getGZCLPProgress(); progress.Stages["T1/Squat"] != "10x1" {
+		t.Errorf("expected squat to stay on 10x1 after dismissing, got %q", progress.Stages["T1/Squat"])
+	}
+
+	// A saved reset puts the lift back on its first stage
+	seedWorkout(t, "2026-03-09", "gzclp", 1, []Exercise{{Name: "Squat", Tier: "T1", Stage: "10x1", Sets: tenSingles(115, 0)}})
+	if w := postGZCLPReset(t, `{"exercise_name": "Squat", "tier": "T1", "test_weight": 110}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if progress, _ := getGZCLPProgress(); progress.Stages["T1/Squat"] != "5x3" {
+		t.Errorf("expected squat back on 5x3 after the reset, got %q", progress.Stages["T1/Squat"])
+	}
+}
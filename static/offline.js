@@ -0,0 +1,144 @@
+// Offline support shared by every page: registers the service worker and
+// keeps a queue of workouts logged without a connection. Queued workouts are
+// replayed against /api/sync whenever the browser comes back online.
+(function() {
+    const QUEUE_KEY = 'trucker-sync-queue';
+    const CONFLICTS_KEY = 'trucker-sync-conflicts';
+
+    if ('serviceWorker' in navigator) {
+        navigator.serviceWorker.register('/sw.js').catch(error => console.error('Error registering service worker:', error));
+    }
+
+    function load(key) {
+        try {
+            return JSON.parse(localStorage.getItem(key)) || [];
+        } catch (e) {
+            return [];
+        }
+    }
+
+    function store(key, items) {
+        localStorage.setItem(key, JSON.stringify(items));
+    }
+
+    function newClientID() {
+        if (window.crypto && crypto.randomUUID) {
+            return crypto.randomUUID();
+        }
+        return Date.now().toString(36) + '-' + Math.random().toString(36).slice(2);
+    }
+
+    function queueWorkout(workout, draftForm) {
+        const queue = load(QUEUE_KEY);
+        queue.push({ client_id: newClientID(), draft_form: draftForm || '', workout: workout });
+        store(QUEUE_KEY, queue);
+        renderStatus();
+    }
+
+    let syncing = false;
+
+    function flushQueue() {
+        const queue = load(QUEUE_KEY);
+        if (syncing || queue.length === 0 || !navigator.onLine) {
+            renderStatus();
+            return Promise.resolve();
+        }
+
+        syncing = true;
+        return fetch('/api/sync', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ workouts: queue })
+        })
+        .then(response => {
+            if (!response.ok) throw new Error('sync failed with ' + response.status);
+            return response.json();
+        })
+        .then(data => {
+            const handled = {};
+            const conflicts = load(CONFLICTS_KEY);
+            data.results.forEach(result => {
+                handled[result.client_id] = true;
+                if (result.status === 'conflict') {
+                    const item = queue.find(q => q.client_id === result.client_id);
+                    conflicts.push(Object.assign({}, item, { message: result.message }));
+                } else if (result.status === 'invalid') {
+                    console.warn('Dropped queued workout ' + result.client_id + ': ' + result.message);
+                }
+            });
+            // Keep anything queued while the request was in flight
+            store(QUEUE_KEY, load(QUEUE_KEY).filter(item => !handled[item.client_id]));
+            store(CONFLICTS_KEY, conflicts);
+        })
+        .catch(error => console.error('Error syncing queued workouts:', error))
+        .finally(() => {
+            syncing = false;
+            renderStatus();
+        });
+    }
+
+    function resolveConflict(clientID, keep) {
+        const conflicts = load(CONFLICTS_KEY);
+        const item = conflicts.find(c => c.client_id === clientID);
+        store(CONFLICTS_KEY, conflicts.filter(c => c.client_id !== clientID));
+        if (keep && item) {
+            const queue = load(QUEUE_KEY);
+            queue.push({ client_id: item.client_id, draft_form: item.draft_form, force: true, workout: item.workout });
+            store(QUEUE_KEY, queue);
+            flushQueue();
+        }
+        renderStatus();
+    }
+
+    function renderStatus() {
+        let panel = document.getElementById('sync-status');
+        if (!panel) {
+            if (!document.body) return;
+            panel = document.createElement('div');
+            panel.id = 'sync-status';
+            panel.className = 'fixed bottom-4 left-4 right-4 md:left-auto md:w-96 z-50 flex flex-col gap-2 text-sm';
+            document.body.appendChild(panel);
+        }
+
+        const queue = load(QUEUE_KEY);
+        const conflicts = load(CONFLICTS_KEY);
+        panel.innerHTML = '';
+
+        if (queue.length > 0) {
+            const note = document.createElement('div');
+            note.className = 'bg-amber-50 border border-amber-300 text-amber-800 rounded-md p-3 shadow';
+            note.textContent = queue.length + ' workout' + (queue.length === 1 ? '' : 's') +
+                (navigator.onLine ? ' syncing…' : ' saved offline, will sync when back online');
+            panel.appendChild(note);
+        }
+
+        conflicts.forEach(item => {
+            const card = document.createElement('div');
+            card.className = 'bg-red-50 border border-red-300 text-red-800 rounded-md p-3 shadow';
+            const text = document.createElement('p');
+            text.className = 'm-0 mb-2';
+            text.textContent = 'Offline workout from ' + item.workout.date + ' was not synced: ' + item.message;
+            const actions = document.createElement('div');
+            actions.className = 'flex gap-2';
+            const keep = document.createElement('button');
+            keep.className = 'py-1 px-3 bg-red-600 text-white border-none rounded-md cursor-pointer hover:bg-red-700';
+            keep.textContent = 'Save anyway';
+            keep.onclick = () => resolveConflict(item.client_id, true);
+            const drop = document.createElement('button');
+            drop.className = 'py-1 px-3 bg-gray-200 border-none rounded-md cursor-pointer hover:bg-gray-300';
+            drop.textContent = 'Discard';
+            drop.onclick = () => resolveConflict(item.client_id, false);
+            actions.appendChild(keep);
+            actions.appendChild(drop);
+            card.appendChild(text);
+            card.appendChild(actions);
+            panel.appendChild(card);
+        });
+    }
+
+    window.addEventListener('online', flushQueue);
+    window.addEventListener('offline', renderStatus);
+    document.addEventListener('DOMContentLoaded', flushQueue);
+
+    window.truckerOffline = { queueWorkout: queueWorkout, flushQueue: flushQueue };
+})();
@@ -0,0 +1,83 @@
+// Trucker service worker: keeps the workout forms and exercise list usable
+// without a connection. Pages and API reads go to the network first and fall
+// back to the last cached copy (uncached pages get the home page, uncached API
+// reads a 503); static assets are served from the cache.
+const CACHE = 'trucker-v1';
+
+const PRECACHE = [
+    '/',
+    '/workout/new',
+    '/gzclp',
+    '/api/exercises',
+    '/static/offline.js',
+    '/static/manifest.json',
+    '/static/logo.jpeg'
+];
+
+self.addEventListener('install', event => {
+    event.waitUntil(
+        caches.open(CACHE)
+            .then(cache => cache.addAll(PRECACHE))
+            .then(() => self.skipWaiting())
+    );
+});
+
+self.addEventListener('activate', event => {
+    event.waitUntil(
+        caches.keys()
+            .then(keys => Promise.all(keys.filter(key => key !== CACHE).map(key => caches.delete(key))))
+            .then(() => self.clients.claim())
+    );
+});
+
+function networkFirst(request) {
+    return fetch(request)
+        .then(response => {
+            if (response.ok) {
+                const copy = response.clone();
+                caches.open(CACHE).then(cache => cache.put(request, copy));
+            }
+            return response;
+        })
+        .catch(() => {
+            const navigate = request.mode === 'navigate';
+            return caches.match(request, { ignoreSearch: navigate })
+                .then(cached => cached || (navigate ? caches.match('/') : offlineResponse()));
+        });
+}
+
+// offlineResponse answers API reads that were never cached, so callers see
+// a failed request instead of the home page's HTML.
+function offlineResponse() {
+    return new Response(JSON.stringify({ error: 'offline' }), {
+        status: 503,
+        headers: { 'Content-Type': 'application/json' }
+    });
+}
+
+function cacheFirst(request) {
+    return caches.match(request).then(cached => cached || fetch(request).then(response => {
+        // Cross-origin CDN scripts come back opaque, which is still worth keeping
+        if (response.ok || response.type === 'opaque') {
+            const copy = response.clone();
+            caches.open(CACHE).then(cache => cache.put(request, copy));
+        }
+        return response;
+    }));
+}
+
+self.addEventListener('fetch', event => {
+    const request = event.request;
+    if (request.method !== 'GET') {
+        return; // Submissions are queued by the page, not the worker
+    }
+
+    const url = new URL(request.url);
+    if (url.origin !== self.location.origin || url.pathname.startsWith('/static/')) {
+        event.respondWith(cacheFirst(request));
+    } else if (url.pathname === '/api/export') {
+        return;
+    } else {
+        event.respondWith(networkFirst(request));
+    }
+});